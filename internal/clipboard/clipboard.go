@@ -0,0 +1,78 @@
+// Package clipboard holds clipboard text captured from the controlled
+// host. HID is output-only, so there is no way to read the guest's
+// clipboard directly; instead a guest-side agent (a serial/UART bridge,
+// a cron job, ...) can write captured text to a drop-box file that
+// WatchFile polls, making it available to the browser via Store.Get.
+package clipboard
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store holds the most recently captured clipboard text, safe for
+// concurrent use by the HTTP handler serving it and whatever populates
+// it (e.g. WatchFile).
+type Store struct {
+	mu   sync.RWMutex
+	text string
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Set replaces the stored clipboard text.
+func (s *Store) Set(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.text = text
+}
+
+// Get returns the most recently stored clipboard text, or "" if none
+// has been captured yet.
+func (s *Store) Get() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.text
+}
+
+// WatchFile polls path at the given interval and, whenever its mtime
+// advances, reads its contents into s. This is the simplest drop-box
+// integration: whatever captures the guest's clipboard just needs to
+// write the text to path. WatchFile returns immediately; the polling
+// runs in a background goroutine that stops once ctx is canceled.
+func (s *Store) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	go func() {
+		var lastMod time.Time
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				if !info.ModTime().After(lastMod) {
+					continue
+				}
+				lastMod = info.ModTime()
+
+				data, err := os.ReadFile(path)
+				if err != nil {
+					log.Printf("clipboard: failed to read drop-box %s: %v", path, err)
+					continue
+				}
+				s.Set(string(data))
+			}
+		}
+	}()
+}
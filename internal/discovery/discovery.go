@@ -0,0 +1,222 @@
+// Package discovery implements a small UDP announce/query protocol so
+// kindavmd instances can be found on the LAN without hard-coded IPs.
+// Each daemon runs a Beacon that periodically broadcasts an Info packet
+// and answers unicast queries on the same port; a Discoverer listens
+// for both to enumerate reachable daemons.
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Capability identifiers advertised in Info.Capabilities.
+const (
+	CapabilityHID       = "hid"
+	CapabilityH264      = "h264"
+	CapabilityUstreamer = "ustreamer"
+)
+
+// Info describes a reachable kindavmd instance.
+type Info struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	HTTPAddr     string   `json:"http_addr"`
+	VideoAddr    string   `json:"video_addr"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// queryPayload is the (otherwise content-free) datagram a Discoverer
+// sends to prompt an immediate reply from any listening Beacon.
+const queryPayload = "kindavm-discover"
+
+// Beacon periodically broadcasts Info on the LAN and answers unicast
+// queries addressed to the same port with the same payload.
+type Beacon struct {
+	port int
+	info Info
+}
+
+// NewBeacon creates a Beacon that advertises info on port.
+func NewBeacon(port int, info Info) *Beacon {
+	return &Beacon{port: port, info: info}
+}
+
+// Run listens for queries and broadcasts info every interval until ctx
+// is canceled.
+func (b *Beacon) Run(ctx context.Context, interval time.Duration) error {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: b.port})
+	if err != nil {
+		return fmt.Errorf("failed to listen for discovery queries: %w", err)
+	}
+	defer conn.Close()
+
+	if err := setBroadcast(conn); err != nil {
+		return fmt.Errorf("failed to enable broadcast on discovery socket: %w", err)
+	}
+
+	go b.serve(ctx, conn)
+
+	broadcastAddr := &net.UDPAddr{IP: net.IPv4bcast, Port: b.port}
+	if err := b.announce(conn, broadcastAddr); err != nil {
+		log.Printf("discovery: failed to broadcast: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := b.announce(conn, broadcastAddr); err != nil {
+				log.Printf("discovery: failed to broadcast: %v", err)
+			}
+		}
+	}
+}
+
+// setBroadcast sets SO_BROADCAST on conn's underlying socket. Without it,
+// the kernel rejects sends to a broadcast address (e.g. 255.255.255.255)
+// with EACCES, which would otherwise silently defeat announce and Query.
+func setBroadcast(conn *net.UDPConn) error {
+	sc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sockErr error
+	if err := sc.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_BROADCAST, 1)
+	}); err != nil {
+		return err
+	}
+	return sockErr
+}
+
+func (b *Beacon) announce(conn *net.UDPConn, addr *net.UDPAddr) error {
+	data, err := json.Marshal(b.info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal discovery info: %w", err)
+	}
+	_, err = conn.WriteToUDP(data, addr)
+	return err
+}
+
+// serve answers unicast queries received on conn with the Info payload,
+// until ctx is canceled. Only a datagram carrying queryPayload is
+// treated as a query; anything else, such as an Info broadcast from this
+// or another Beacon, is ignored.
+func (b *Beacon) serve(ctx context.Context, conn *net.UDPConn) {
+	buf := make([]byte, 512)
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			continue // read timeout (or transient error); loop back to check ctx
+		}
+		if string(buf[:n]) != queryPayload {
+			continue
+		}
+
+		data, err := json.Marshal(b.info)
+		if err != nil {
+			log.Printf("discovery: failed to marshal info: %v", err)
+			continue
+		}
+		if _, err := conn.WriteToUDP(data, addr); err != nil {
+			log.Printf("discovery: failed to reply to %s: %v", addr, err)
+		}
+	}
+}
+
+// Discoverer listens for Beacon broadcasts (and replies to Query) to
+// enumerate reachable kindavmd instances on the LAN.
+type Discoverer struct {
+	conn    *net.UDPConn
+	devices chan Info
+}
+
+// NewDiscoverer starts listening for Info broadcasts/replies on port,
+// which should match the Beacon port of the daemons being discovered.
+func NewDiscoverer(port int) (*Discoverer, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: port})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for discovery broadcasts: %w", err)
+	}
+
+	if err := setBroadcast(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable broadcast on discovery socket: %w", err)
+	}
+
+	d := &Discoverer{conn: conn, devices: make(chan Info, 16)}
+	go d.listen()
+	return d, nil
+}
+
+func (d *Discoverer) listen() {
+	defer close(d.devices)
+
+	buf := make([]byte, 512)
+	for {
+		n, _, err := d.conn.ReadFromUDP(buf)
+		if err != nil {
+			return // conn closed
+		}
+
+		var info Info
+		if err := json.Unmarshal(buf[:n], &info); err != nil {
+			continue
+		}
+
+		select {
+		case d.devices <- info:
+		default:
+			// Consumer isn't keeping up; drop rather than block the reader.
+		}
+	}
+}
+
+// Query broadcasts a discovery request so any listening Beacon replies
+// immediately, instead of waiting for its next periodic announcement.
+func (d *Discoverer) Query() error {
+	localAddr, ok := d.conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return fmt.Errorf("discoverer has no local UDP address")
+	}
+	addr := &net.UDPAddr{IP: net.IPv4bcast, Port: localAddr.Port}
+	_, err := d.conn.WriteToUDP([]byte(queryPayload), addr)
+	return err
+}
+
+// NextDevice blocks until another device is discovered or ctx is
+// canceled. Callers loop on NextDevice to enumerate devices as they
+// appear, mirroring how a browser front-end would poll GET /info on
+// each candidate it learns about.
+func (d *Discoverer) NextDevice(ctx context.Context) (Info, error) {
+	select {
+	case info, ok := <-d.devices:
+		if !ok {
+			return Info{}, fmt.Errorf("discoverer closed")
+		}
+		return info, nil
+	case <-ctx.Done():
+		return Info{}, ctx.Err()
+	}
+}
+
+// Close stops listening for broadcasts.
+func (d *Discoverer) Close() error {
+	return d.conn.Close()
+}
@@ -0,0 +1,114 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// freePort asks the OS for an ephemeral UDP port, then releases it so a
+// Beacon can bind to the same number.
+func freePort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		t.Fatalf("failed to allocate a free port: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// TestSetBroadcastEnablesSockopt verifies that setBroadcast actually
+// flips SO_BROADCAST on the socket, which is what lets Beacon.announce
+// send to 255.255.255.255 without the kernel rejecting it with EACCES.
+func TestSetBroadcastEnablesSockopt(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		t.Fatalf("ListenUDP error = %v", err)
+	}
+	defer conn.Close()
+
+	if err := setBroadcast(conn); err != nil {
+		t.Fatalf("setBroadcast error = %v", err)
+	}
+
+	sc, err := conn.SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn error = %v", err)
+	}
+	var val int
+	var sockErr error
+	if err := sc.Control(func(fd uintptr) {
+		val, sockErr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_BROADCAST)
+	}); err != nil {
+		t.Fatalf("Control error = %v", err)
+	}
+	if sockErr != nil {
+		t.Fatalf("GetsockoptInt(SO_BROADCAST) error = %v", sockErr)
+	}
+	if val == 0 {
+		t.Fatal("expected SO_BROADCAST to be enabled after setBroadcast")
+	}
+}
+
+// TestBeaconServeRepliesToQuery verifies the unicast query/reply half of
+// the protocol: a query datagram addressed directly to the Beacon's port
+// gets an Info reply back, matching how Discoverer.Query prompts an
+// immediate announcement.
+func TestBeaconServeRepliesToQuery(t *testing.T) {
+	port := freePort(t)
+	info := Info{Name: "kindavm-test", HTTPAddr: "127.0.0.1:8080"}
+	b := NewBeacon(port, info)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- b.Run(ctx, time.Hour) }()
+
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	if err != nil {
+		t.Fatalf("DialUDP error = %v", err)
+	}
+	defer conn.Close()
+
+	var got Info
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := conn.Write([]byte(queryPayload)); err != nil {
+			t.Fatalf("Write(query) error = %v", err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		buf := make([]byte, 512)
+		n, err := conn.Read(buf)
+		if err == nil {
+			if jsonErr := json.Unmarshal(buf[:n], &got); jsonErr != nil {
+				t.Fatalf("failed to unmarshal reply: %v", jsonErr)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for a reply: %v", err)
+		}
+	}
+
+	if got.Name != info.Name {
+		t.Fatalf("got.Name = %q, want %q", got.Name, info.Name)
+	}
+	if got.HTTPAddr != info.HTTPAddr {
+		t.Fatalf("got.HTTPAddr = %q, want %q", got.HTTPAddr, info.HTTPAddr)
+	}
+
+	cancel()
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Fatalf("Run error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Run to return after cancel")
+	}
+}
@@ -2,9 +2,12 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 
+	"github.com/Ch00k/kindavm/internal/clipboard"
 	"github.com/Ch00k/kindavm/internal/hid"
 )
 
@@ -33,17 +36,21 @@ const (
 	EventCtrlF4         EventType = "ctrl_f4"
 	EventAltF4          EventType = "alt_f4"
 	EventF11            EventType = "f11"
+	EventClipboardPaste EventType = "clipboard_paste"
+	EventClipboardCopy  EventType = "clipboard_copy"
 )
 
 // BrowserEvent represents an event from the browser
 type BrowserEvent struct {
 	Type      EventType `json:"type"`
-	Code      string    `json:"code,omitempty"`      // For keyboard events
+	Code      string    `json:"code,omitempty"`      // For keyboard events: KeyboardEvent.code, the physical key
+	Key       string    `json:"key,omitempty"`       // For keyboard events: KeyboardEvent.key, the layout-resolved character
 	Modifiers []string  `json:"modifiers,omitempty"` // For keyboard events
 	X         int       `json:"x,omitempty"`         // For mouse move events
 	Y         int       `json:"y,omitempty"`         // For mouse move events
 	Button    string    `json:"button,omitempty"`    // For mouse button events
 	Delta     int       `json:"delta,omitempty"`     // For wheel events
+	Text      string    `json:"text,omitempty"`      // For clipboard_paste events
 }
 
 // Handler processes browser events and sends HID reports
@@ -51,8 +58,21 @@ type Handler struct {
 	keyboard       *hid.Keyboard
 	mouse          *hid.Mouse
 	consumer       *hid.Consumer
-	pressedKeys    map[string]bool // Track currently pressed keys
-	pressedButtons map[string]bool // Track currently pressed mouse buttons
+	pressedKeys    map[string]string // Track currently pressed keys: event.Code -> event.Key
+	pressedButtons map[string]bool   // Track currently pressed mouse buttons
+	clipboard      *clipboard.Store
+
+	// macroMu serializes every call to HandleEvent, so macro playback and
+	// live browser input never race sending reports or mutating
+	// pressedKeys/pressedButtons.
+	macroMu sync.Mutex
+
+	// recorder is non-nil while a macro recording is in progress.
+	recorderMu sync.Mutex
+	recorder   *MacroRecorder
+
+	macrosMu sync.RWMutex
+	macros   map[string]MacroScript
 }
 
 // NewHandler creates a new event handler
@@ -61,18 +81,59 @@ func NewHandler(device *hid.Device) *Handler {
 		keyboard:       hid.NewKeyboard(device),
 		mouse:          hid.NewMouse(device),
 		consumer:       hid.NewConsumer(device),
-		pressedKeys:    make(map[string]bool),
+		pressedKeys:    make(map[string]string),
 		pressedButtons: make(map[string]bool),
+		macros:         make(map[string]MacroScript),
 	}
 }
 
-// HandleEvent processes a browser event and sends appropriate HID reports
+// NewHandlerWithClipboard creates a new event handler that also serves
+// clipboard_copy/clipboard_paste events, storing captured clipboard text
+// in store so it can be surfaced elsewhere (e.g. a web.Server endpoint).
+func NewHandlerWithClipboard(device *hid.Device, store *clipboard.Store) *Handler {
+	h := NewHandler(device)
+	h.clipboard = store
+	return h
+}
+
+// WatchCancel starts a goroutine that releases every held key, modifier
+// and mouse button, both on the guest and in this Handler's own
+// pressedKeys/pressedButtons bookkeeping, as soon as ctx is canceled -
+// so a dropped WebSocket connection never leaves Ctrl, Shift or a mouse
+// button latched on the guest.
+func (h *Handler) WatchCancel(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+
+		h.macroMu.Lock()
+		defer h.macroMu.Unlock()
+
+		h.pressedKeys = make(map[string]string)
+		h.pressedButtons = make(map[string]bool)
+		_ = h.keyboard.ReleaseAll()
+		_ = h.mouse.ReleaseButton()
+	}()
+}
+
+// HandleEvent processes a browser event and sends appropriate HID reports.
+// Locking macroMu for the duration of each call, rather than for a whole
+// macro playback, is what lets a played-back macro and live browser
+// input interleave safely instead of one blocking the other entirely.
 func (h *Handler) HandleEvent(data []byte) error {
 	var event BrowserEvent
 	if err := json.Unmarshal(data, &event); err != nil {
 		return fmt.Errorf("failed to unmarshal event: %w", err)
 	}
 
+	h.macroMu.Lock()
+	defer h.macroMu.Unlock()
+
+	h.recorderMu.Lock()
+	if h.recorder != nil {
+		h.recorder.Record(event)
+	}
+	h.recorderMu.Unlock()
+
 	switch event.Type {
 	case EventKeyDown:
 		return h.handleKeyDown(event)
@@ -114,6 +175,10 @@ func (h *Handler) HandleEvent(data []byte) error {
 		return h.handleAltF4()
 	case EventF11:
 		return h.handleF11()
+	case EventClipboardPaste:
+		return h.handleClipboardPaste(event)
+	case EventClipboardCopy:
+		return h.handleClipboardCopy()
 	default:
 		return fmt.Errorf("unknown event type: %s", event.Type)
 	}
@@ -125,7 +190,7 @@ func (h *Handler) handleKeyDown(event BrowserEvent) error {
 	}
 
 	// Track that this key is pressed
-	h.pressedKeys[event.Code] = true
+	h.pressedKeys[event.Code] = event.Key
 
 	// Calculate modifier byte from modifiers array
 	modifier := h.calculateModifier(event.Modifiers)
@@ -228,17 +293,25 @@ func (h *Handler) calculateModifier(modifiers []string) byte {
 	return modifier
 }
 
-// getKeycodes returns HID keycodes for all currently pressed keys (up to 6)
+// getKeycodes returns HID keycodes for all currently pressed keys (up to 6).
+// A code the client's physical layout doesn't map to a US HID position (e.g.
+// a non-US keyboard's punctuation keys) falls back to resolving the
+// layout-resolved event.Key instead of being silently dropped.
 func (h *Handler) getKeycodes() []byte {
 	keycodes := make([]byte, 0, 6)
 
-	for code := range h.pressedKeys {
+	for code, key := range h.pressedKeys {
 		if len(keycodes) >= 6 {
 			break // HID keyboard supports max 6 simultaneous keys
 		}
 
 		if hidCode, exists := hid.BrowserKeyCodeMap[code]; exists {
 			keycodes = append(keycodes, hidCode)
+			continue
+		}
+
+		if _, hidCode, ok := hid.ResolveKey(hid.BrowserKeyEvent{Key: key, Code: code}); ok {
+			keycodes = append(keycodes, hidCode)
 		}
 	}
 
@@ -328,3 +401,33 @@ func (h *Handler) handleAltF4() error {
 func (h *Handler) handleF11() error {
 	return h.keyboard.SendF11()
 }
+
+// handleClipboardPaste types event.Text on the target via the
+// keyboard's configured layout, i.e. "paste" by typing: there is no HID
+// path to write to the guest's clipboard directly.
+func (h *Handler) handleClipboardPaste(event BrowserEvent) error {
+	if event.Text == "" {
+		return fmt.Errorf("clipboard_paste event missing text")
+	}
+	return h.keyboard.Type(event.Text)
+}
+
+// handleClipboardCopy synthesizes Ctrl+C on the target. We cannot read
+// the guest's clipboard back over HID, so this only triggers whatever
+// copy the guest OS would otherwise perform on that shortcut; anything
+// actually captured server-side (e.g. via a serial bridge or drop-box
+// file) surfaces through the clipboard.Store passed to
+// NewHandlerWithClipboard instead.
+func (h *Handler) handleClipboardCopy() error {
+	return h.keyboard.SendCtrlC()
+}
+
+// ClipboardText returns the most recently captured clipboard text, or ""
+// if no clipboard.Store was configured (via NewHandlerWithClipboard) or
+// nothing has been captured yet.
+func (h *Handler) ClipboardText() string {
+	if h.clipboard == nil {
+		return ""
+	}
+	return h.clipboard.Get()
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ch00k/kindavm/internal/hid"
+)
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hidg0")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create fake HID device: %v", err)
+	}
+	_ = f.Close()
+	return NewHandler(hid.NewDevice(path))
+}
+
+// TestWatchCancelReleasesHeldKeys verifies that canceling the context
+// passed to WatchCancel clears any keys and mouse buttons HandleEvent
+// was tracking as pressed, so a dropped connection can't leave a
+// modifier or button latched.
+func TestWatchCancelReleasesHeldKeys(t *testing.T) {
+	h := newTestHandler(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.WatchCancel(ctx)
+
+	keydown := []byte(`{"type":"keydown","code":"ControlLeft","modifiers":["ctrl"]}`)
+	if err := h.HandleEvent(keydown); err != nil {
+		t.Fatalf("HandleEvent(keydown) error = %v", err)
+	}
+	mousedown := []byte(`{"type":"mousedown","button":"left"}`)
+	if err := h.HandleEvent(mousedown); err != nil {
+		t.Fatalf("HandleEvent(mousedown) error = %v", err)
+	}
+
+	h.macroMu.Lock()
+	heldKeys, heldButtons := len(h.pressedKeys), len(h.pressedButtons)
+	h.macroMu.Unlock()
+	if heldKeys == 0 {
+		t.Fatal("expected pressedKeys to be non-empty before cancel")
+	}
+	if heldButtons == 0 {
+		t.Fatal("expected pressedButtons to be non-empty before cancel")
+	}
+
+	cancel()
+
+	deadline := time.After(time.Second)
+	for {
+		h.macroMu.Lock()
+		heldKeys, heldButtons := len(h.pressedKeys), len(h.pressedButtons)
+		h.macroMu.Unlock()
+		if heldKeys == 0 && heldButtons == 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for pressedKeys/pressedButtons to clear after cancel")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
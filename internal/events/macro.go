@@ -0,0 +1,160 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// MacroEvent is a single BrowserEvent timestamped relative to the start
+// of a recording.
+type MacroEvent struct {
+	AtMS  int64        `json:"t_ms"`
+	Event BrowserEvent `json:"event"`
+}
+
+// MacroScript is the JSON-serializable container for a recorded macro.
+type MacroScript struct {
+	Events []MacroEvent `json:"events"`
+}
+
+// MacroRecorder timestamps every BrowserEvent passed to Record relative
+// to when the recorder was created, building up a MacroScript for later
+// replay via Handler.PlayMacro.
+type MacroRecorder struct {
+	start time.Time
+
+	mu     sync.Mutex
+	events []MacroEvent
+}
+
+// NewMacroRecorder creates a MacroRecorder whose timestamps are relative
+// to now.
+func NewMacroRecorder() *MacroRecorder {
+	return &MacroRecorder{start: time.Now()}
+}
+
+// Record appends event to the recording with its offset from when the
+// recorder was created.
+func (r *MacroRecorder) Record(event BrowserEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, MacroEvent{
+		AtMS:  time.Since(r.start).Milliseconds(),
+		Event: event,
+	})
+}
+
+// Script returns the events recorded so far as a MacroScript.
+func (r *MacroRecorder) Script() MacroScript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]MacroEvent, len(r.events))
+	copy(events, r.events)
+	return MacroScript{Events: events}
+}
+
+// StartRecording begins recording every event passed through HandleEvent
+// into a new in-progress macro, discarding any previous recording that
+// was never stopped.
+func (h *Handler) StartRecording() {
+	h.recorderMu.Lock()
+	defer h.recorderMu.Unlock()
+	h.recorder = NewMacroRecorder()
+}
+
+// StopRecording ends the in-progress recording, saves it under name, and
+// returns the recorded script. Returns an error if no recording was in
+// progress.
+func (h *Handler) StopRecording(name string) (MacroScript, error) {
+	h.recorderMu.Lock()
+	recorder := h.recorder
+	h.recorder = nil
+	h.recorderMu.Unlock()
+
+	if recorder == nil {
+		return MacroScript{}, fmt.Errorf("no recording in progress")
+	}
+
+	script := recorder.Script()
+	h.SaveMacro(name, script)
+	return script, nil
+}
+
+// Macro returns the named macro script and whether it exists.
+func (h *Handler) Macro(name string) (MacroScript, bool) {
+	h.macrosMu.RLock()
+	defer h.macrosMu.RUnlock()
+	script, ok := h.macros[name]
+	return script, ok
+}
+
+// SaveMacro stores script under name, overwriting any existing macro
+// with that name.
+func (h *Handler) SaveMacro(name string, script MacroScript) {
+	h.macrosMu.Lock()
+	defer h.macrosMu.Unlock()
+	h.macros[name] = script
+}
+
+// PlayMacro replays the named macro's events through HandleEvent,
+// honoring the original inter-event delays scaled by speed (values
+// above 1.0 play faster, below 1.0 slower). If ctx is canceled
+// mid-playback, every held key and mouse button is released before
+// returning so an aborted macro never leaves input stuck on the guest.
+func (h *Handler) PlayMacro(ctx context.Context, name string, speed float64) error {
+	if speed <= 0 {
+		speed = 1.0
+	}
+
+	script, ok := h.Macro(name)
+	if !ok {
+		return fmt.Errorf("unknown macro: %q", name)
+	}
+
+	var last int64
+	for _, me := range script.Events {
+		wait := time.Duration(float64(me.AtMS-last) * float64(time.Millisecond) / speed)
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				h.releaseAll()
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		last = me.AtMS
+
+		data, err := json.Marshal(me.Event)
+		if err != nil {
+			h.releaseAll()
+			return fmt.Errorf("failed to marshal macro event: %w", err)
+		}
+		if err := h.HandleEvent(data); err != nil {
+			log.Printf("Error replaying macro event: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// releaseAll releases every held key and mouse button and clears
+// tracked press state, recovering from an aborted macro so nothing is
+// left stuck on the guest.
+func (h *Handler) releaseAll() {
+	h.macroMu.Lock()
+	defer h.macroMu.Unlock()
+
+	h.pressedKeys = make(map[string]string)
+	h.pressedButtons = make(map[string]bool)
+
+	if err := h.keyboard.ReleaseKey(); err != nil {
+		log.Printf("Failed to release keys after macro abort: %v", err)
+	}
+	if err := h.mouse.ReleaseButton(); err != nil {
+		log.Printf("Failed to release mouse buttons after macro abort: %v", err)
+	}
+}
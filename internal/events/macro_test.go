@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestMacroRecordPlayRoundTrip verifies that a recorded macro replays its
+// events and that increasing speed shortens playback proportionally.
+func TestMacroRecordPlayRoundTrip(t *testing.T) {
+	h := newTestHandler(t)
+
+	h.StartRecording()
+	keydown := []byte(`{"type":"keydown","code":"KeyA"}`)
+	if err := h.HandleEvent(keydown); err != nil {
+		t.Fatalf("HandleEvent(keydown) error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	keyup := []byte(`{"type":"keyup","code":"KeyA"}`)
+	if err := h.HandleEvent(keyup); err != nil {
+		t.Fatalf("HandleEvent(keyup) error = %v", err)
+	}
+
+	script, err := h.StopRecording("test")
+	if err != nil {
+		t.Fatalf("StopRecording error = %v", err)
+	}
+	if len(script.Events) != 2 {
+		t.Fatalf("len(script.Events) = %d, want 2", len(script.Events))
+	}
+
+	if _, ok := h.Macro("test"); !ok {
+		t.Fatal("expected macro \"test\" to be saved")
+	}
+
+	start := time.Now()
+	if err := h.PlayMacro(context.Background(), "test", 1.0); err != nil {
+		t.Fatalf("PlayMacro(speed=1.0) error = %v", err)
+	}
+	normal := time.Since(start)
+
+	start = time.Now()
+	if err := h.PlayMacro(context.Background(), "test", 4.0); err != nil {
+		t.Fatalf("PlayMacro(speed=4.0) error = %v", err)
+	}
+	fast := time.Since(start)
+
+	if fast >= normal {
+		t.Fatalf("playback at speed=4.0 (%v) did not run faster than speed=1.0 (%v)", fast, normal)
+	}
+}
+
+// TestPlayMacroCancelReleasesHeldKeys verifies that canceling the context
+// passed to PlayMacro mid-playback releases every held key and mouse
+// button, mirroring TestWatchCancelReleasesHeldKeys.
+func TestPlayMacroCancelReleasesHeldKeys(t *testing.T) {
+	h := newTestHandler(t)
+
+	script := MacroScript{Events: []MacroEvent{
+		{AtMS: 0, Event: BrowserEvent{Type: EventKeyDown, Code: "ControlLeft", Modifiers: []string{"ctrl"}}},
+		{AtMS: 0, Event: BrowserEvent{Type: EventMouseDown, Button: "left"}},
+		{AtMS: 10_000, Event: BrowserEvent{Type: EventKeyUp, Code: "ControlLeft"}},
+	}}
+	h.SaveMacro("abort", script)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- h.PlayMacro(ctx, "abort", 1.0)
+	}()
+
+	deadline := time.After(time.Second)
+	for {
+		h.macroMu.Lock()
+		heldKeys, heldButtons := len(h.pressedKeys), len(h.pressedButtons)
+		h.macroMu.Unlock()
+		if heldKeys > 0 && heldButtons > 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for PlayMacro to press the key and mouse button")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err != context.Canceled {
+			t.Fatalf("PlayMacro error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for PlayMacro to return after cancel")
+	}
+
+	h.macroMu.Lock()
+	heldKeys, heldButtons := len(h.pressedKeys), len(h.pressedButtons)
+	h.macroMu.Unlock()
+	if heldKeys != 0 {
+		t.Fatalf("pressedKeys not cleared after cancel: %d entries", heldKeys)
+	}
+	if heldButtons != 0 {
+		t.Fatalf("pressedButtons not cleared after cancel: %d entries", heldButtons)
+	}
+}
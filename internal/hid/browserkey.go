@@ -0,0 +1,103 @@
+package hid
+
+// KeyStroke carries the modifier and keycode needed to produce a
+// KeyboardEvent.key value.
+type KeyStroke struct {
+	Modifier byte
+	Keycode  byte
+}
+
+// BrowserKeyEvent represents the subset of a browser KeyboardEvent
+// needed to resolve it to a HID key stroke.
+type BrowserKeyEvent struct {
+	Key  string // KeyboardEvent.key, the layout-resolved character (e.g. "a", "Ö", "ArrowUp")
+	Code string // KeyboardEvent.code, the physical key position (e.g. "KeyA")
+}
+
+// BrowserKeyMap maps browser KeyboardEvent.key values (layout-resolved
+// characters) to HID Usage IDs on a US keyboard. Unlike BrowserKeyCodeMap,
+// this is keyed by the character the guest is expected to receive rather
+// than the physical key the client pressed, which matters when the guest
+// OS runs a different layout than the client browser.
+var BrowserKeyMap = map[string]KeyStroke{
+	// Lowercase letters
+	"a": {ModNone, 0x04}, "b": {ModNone, 0x05}, "c": {ModNone, 0x06}, "d": {ModNone, 0x07},
+	"e": {ModNone, 0x08}, "f": {ModNone, 0x09}, "g": {ModNone, 0x0A}, "h": {ModNone, 0x0B},
+	"i": {ModNone, 0x0C}, "j": {ModNone, 0x0D}, "k": {ModNone, 0x0E}, "l": {ModNone, 0x0F},
+	"m": {ModNone, 0x10}, "n": {ModNone, 0x11}, "o": {ModNone, 0x12}, "p": {ModNone, 0x13},
+	"q": {ModNone, 0x14}, "r": {ModNone, 0x15}, "s": {ModNone, 0x16}, "t": {ModNone, 0x17},
+	"u": {ModNone, 0x18}, "v": {ModNone, 0x19}, "w": {ModNone, 0x1A}, "x": {ModNone, 0x1B},
+	"y": {ModNone, 0x1C}, "z": {ModNone, 0x1D},
+
+	// Uppercase letters (Shift + same physical key)
+	"A": {ModLeftShift, 0x04}, "B": {ModLeftShift, 0x05}, "C": {ModLeftShift, 0x06}, "D": {ModLeftShift, 0x07},
+	"E": {ModLeftShift, 0x08}, "F": {ModLeftShift, 0x09}, "G": {ModLeftShift, 0x0A}, "H": {ModLeftShift, 0x0B},
+	"I": {ModLeftShift, 0x0C}, "J": {ModLeftShift, 0x0D}, "K": {ModLeftShift, 0x0E}, "L": {ModLeftShift, 0x0F},
+	"M": {ModLeftShift, 0x10}, "N": {ModLeftShift, 0x11}, "O": {ModLeftShift, 0x12}, "P": {ModLeftShift, 0x13},
+	"Q": {ModLeftShift, 0x14}, "R": {ModLeftShift, 0x15}, "S": {ModLeftShift, 0x16}, "T": {ModLeftShift, 0x17},
+	"U": {ModLeftShift, 0x18}, "V": {ModLeftShift, 0x19}, "W": {ModLeftShift, 0x1A}, "X": {ModLeftShift, 0x1B},
+	"Y": {ModLeftShift, 0x1C}, "Z": {ModLeftShift, 0x1D},
+
+	// Digit row and shifted symbols
+	"1": {ModNone, 0x1E}, "!": {ModLeftShift, 0x1E},
+	"2": {ModNone, 0x1F}, "@": {ModLeftShift, 0x1F},
+	"3": {ModNone, 0x20}, "#": {ModLeftShift, 0x20},
+	"4": {ModNone, 0x21}, "$": {ModLeftShift, 0x21},
+	"5": {ModNone, 0x22}, "%": {ModLeftShift, 0x22},
+	"6": {ModNone, 0x23}, "^": {ModLeftShift, 0x23},
+	"7": {ModNone, 0x24}, "&": {ModLeftShift, 0x24},
+	"8": {ModNone, 0x25}, "*": {ModLeftShift, 0x25},
+	"9": {ModNone, 0x26}, "(": {ModLeftShift, 0x26},
+	"0": {ModNone, 0x27}, ")": {ModLeftShift, 0x27},
+
+	// Punctuation
+	"-": {ModNone, 0x2D}, "_": {ModLeftShift, 0x2D},
+	"=": {ModNone, 0x2E}, "+": {ModLeftShift, 0x2E},
+	"[": {ModNone, 0x2F}, "{": {ModLeftShift, 0x2F},
+	"]": {ModNone, 0x30}, "}": {ModLeftShift, 0x30},
+	"\\": {ModNone, 0x31}, "|": {ModLeftShift, 0x31},
+	";": {ModNone, 0x33}, ":": {ModLeftShift, 0x33},
+	"'": {ModNone, 0x34}, "\"": {ModLeftShift, 0x34},
+	"`": {ModNone, 0x35}, "~": {ModLeftShift, 0x35},
+	",": {ModNone, 0x36}, "<": {ModLeftShift, 0x36},
+	".": {ModNone, 0x37}, ">": {ModLeftShift, 0x37},
+	"/": {ModNone, 0x38}, "?": {ModLeftShift, 0x38},
+
+	// Named keys. These carry the same keycode as BrowserKeyCodeMap since
+	// KeyboardEvent.key reports the same name regardless of layout.
+	"Enter":       {ModNone, 0x28},
+	"Escape":      {ModNone, 0x29},
+	"Backspace":   {ModNone, 0x2A},
+	"Tab":         {ModNone, 0x2B},
+	" ":           {ModNone, 0x2C},
+	"CapsLock":    {ModNone, 0x39},
+	"PrintScreen": {ModNone, 0x46},
+	"ScrollLock":  {ModNone, 0x47},
+	"Pause":       {ModNone, 0x48},
+	"Insert":      {ModNone, 0x49},
+	"Home":        {ModNone, 0x4A},
+	"PageUp":      {ModNone, 0x4B},
+	"Delete":      {ModNone, 0x4C},
+	"End":         {ModNone, 0x4D},
+	"PageDown":    {ModNone, 0x4E},
+	"ArrowRight":  {ModNone, 0x4F},
+	"ArrowLeft":   {ModNone, 0x50},
+	"ArrowDown":   {ModNone, 0x51},
+	"ArrowUp":     {ModNone, 0x52},
+	"ContextMenu": {ModNone, 0x65},
+}
+
+// ResolveKey resolves a browser KeyboardEvent to a HID modifier and
+// keycode. It prefers the layout-resolved event.Key (so the guest
+// receives the character the user actually typed even if the guest
+// keyboard layout differs from the client's), falling back to the
+// physical event.Code when the key value isn't recognized.
+func ResolveKey(event BrowserKeyEvent) (modifier byte, keycode byte, ok bool) {
+	if stroke, exists := BrowserKeyMap[event.Key]; exists {
+		return stroke.Modifier, stroke.Keycode, true
+	}
+	if code, exists := BrowserKeyCodeMap[event.Code]; exists {
+		return ModNone, code, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,66 @@
+package hid
+
+import "testing"
+
+func TestBrowserKeyMap(t *testing.T) {
+	tests := []struct {
+		key      string
+		modifier byte
+		keycode  byte
+	}{
+		{"a", ModNone, 0x04},
+		{"A", ModLeftShift, 0x04},
+		{"@", ModLeftShift, 0x1F},
+		{"ArrowUp", ModNone, 0x52},
+		{"Dead", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			stroke, exists := BrowserKeyMap[tt.key]
+			if tt.keycode == 0 && tt.modifier == 0 {
+				if exists {
+					t.Errorf("BrowserKeyMap[%q] unexpectedly exists", tt.key)
+				}
+				return
+			}
+			if !exists {
+				t.Fatalf("BrowserKeyMap[%q] does not exist", tt.key)
+			}
+			if stroke.Modifier != tt.modifier || stroke.Keycode != tt.keycode {
+				t.Errorf("BrowserKeyMap[%q] = %+v, want {%#02x, %#02x}", tt.key, stroke, tt.modifier, tt.keycode)
+			}
+		})
+	}
+}
+
+func TestResolveKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    BrowserKeyEvent
+		modifier byte
+		keycode  byte
+		ok       bool
+	}{
+		{"prefers key over code", BrowserKeyEvent{Key: "a", Code: "KeyQ"}, ModNone, 0x04, true},
+		{"falls back to code when key unknown", BrowserKeyEvent{Key: "Dead", Code: "KeyA"}, ModNone, 0x04, true},
+		{"unresolvable event", BrowserKeyEvent{Key: "Dead", Code: "Nonsense"}, 0, 0, false},
+		{"unknown key falls back to physical code", BrowserKeyEvent{Key: "Ö", Code: "Semicolon"}, ModNone, 0x33, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modifier, keycode, ok := ResolveKey(tt.event)
+			if ok != tt.ok {
+				t.Fatalf("ResolveKey(%+v) ok = %v, want %v", tt.event, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if modifier != tt.modifier || keycode != tt.keycode {
+				t.Errorf("ResolveKey(%+v) = (%#02x, %#02x), want (%#02x, %#02x)",
+					tt.event, modifier, keycode, tt.modifier, tt.keycode)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package hid
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Role identifies a HID gadget function by the control surface it
+// exposes, so Composite can route reports to the right /dev/hidgN
+// without callers hardcoding device paths.
+type Role string
+
+// Supported gadget function roles.
+const (
+	RoleKeyboard        Role = "keyboard"
+	RoleMouse           Role = "mouse"
+	RoleAbsolutePointer Role = "absolute_pointer"
+	RoleConsumerControl Role = "consumer_control"
+)
+
+// autoDetectRoles are the roles NewComposite will try to assign by
+// matching report descriptors, in this order. RoleAbsolutePointer is
+// deliberately excluded: its descriptor declares the same Generic
+// Desktop/Mouse usage as RoleMouse (only the report ID on the wire
+// tells them apart), so it can't be told apart from a descriptor alone
+// and must be pinned via CompositeConfig.
+var autoDetectRoles = []Role{RoleKeyboard, RoleMouse, RoleConsumerControl}
+
+// roleUsage is the (usage page, usage) a gadget function's report
+// descriptor must declare on its top-level collection to satisfy a
+// Role. Used both for auto-detection (autoDetectRoles) and, at
+// Connect(), to sanity-check a role pinned via CompositeConfig.
+var roleUsage = map[Role]descriptorUsage{
+	RoleKeyboard:        {page: usagePageGenericDesktop, usage: usageKeyboard},
+	RoleMouse:           {page: usagePageGenericDesktop, usage: usageMouse},
+	RoleAbsolutePointer: {page: usagePageGenericDesktop, usage: usageMouse},
+	RoleConsumerControl: {page: usagePageConsumer, usage: usageConsumerControl},
+}
+
+// CompositeConfig maps each Role a gadget exposes to the character
+// device path serving it, e.g. {RoleKeyboard: "/dev/hidg0", RoleMouse:
+// "/dev/hidg1"}, pinning it instead of relying on sysfs auto-discovery.
+// RoleAbsolutePointer can only be assigned this way. Roles left unset
+// are filled in by NewComposite from discovered gadget functions.
+type CompositeConfig map[Role]string
+
+// gadgetFunctionsGlob matches configfs's per-function directories for
+// HID gadget functions, one per exposed /dev/hidgN endpoint. A var
+// rather than a const so tests can point it at a fake configfs tree.
+var gadgetFunctionsGlob = "/sys/kernel/config/usb_gadget/*/functions/hid.*"
+
+// gadgetFunction is one discovered HID gadget function: the configfs
+// directory describing it and the /dev/hidgN character device the
+// kernel backs it with.
+type gadgetFunction struct {
+	sysfsPath  string
+	devicePath string
+}
+
+// discoverGadgetFunctions walks gadgetFunctionsGlob and returns one
+// gadgetFunction per HID function directory found, in the order the
+// kernel assigns /dev/hidgN numbers: configfs lists a gadget's hid.*
+// function directories in the same lexical order libcomposite binds
+// them to the UDC in, which is the order hidg minor numbers are handed
+// out.
+func discoverGadgetFunctions() ([]gadgetFunction, error) {
+	matches, err := filepath.Glob(gadgetFunctionsGlob)
+	if err != nil {
+		return nil, fmt.Errorf("hid: failed to glob gadget functions: %w", err)
+	}
+	sort.Strings(matches)
+
+	functions := make([]gadgetFunction, len(matches))
+	for i, sysfsPath := range matches {
+		functions[i] = gadgetFunction{
+			sysfsPath:  sysfsPath,
+			devicePath: fmt.Sprintf("/dev/hidg%d", i),
+		}
+	}
+	return functions, nil
+}
+
+// reportDesc reads the function's configured report descriptor, the
+// same bytes presented to the USB host, straight from configfs.
+func (f gadgetFunction) reportDesc() ([]byte, error) {
+	desc, err := os.ReadFile(filepath.Join(f.sysfsPath, "report_desc"))
+	if err != nil {
+		return nil, fmt.Errorf("hid: failed to read report descriptor for %s: %w", f.devicePath, err)
+	}
+	return desc, nil
+}
+
+// roleDevice is a Role's assigned Device plus, when known, the configfs
+// function directory that backs it, so Connect() can re-check the
+// descriptor even for a role pinned by CompositeConfig.
+type roleDevice struct {
+	device    *Device
+	sysfsPath string
+}
+
+// Composite is a multi-function HID gadget: several /dev/hidgN
+// character devices, each a different control surface, behind one set
+// of typed accessors. Unlike a bare Device shared by Keyboard, Mouse,
+// and Consumer on a single report-ID-multiplexed endpoint, Composite is
+// for gadgets that expose each function as its own character device.
+type Composite struct {
+	roles map[Role]roleDevice
+}
+
+// NewComposite discovers a gadget's HID functions under
+// /sys/kernel/config/usb_gadget and assigns each a Role by matching its
+// report descriptor's top-level usage against roleUsage. config, if
+// non-nil, pins specific roles to specific device paths instead of
+// relying on discovery — required for RoleAbsolutePointer, since its
+// descriptor is indistinguishable from RoleMouse's.
+//
+// NewComposite only discovers and assigns; it does not open any device
+// or validate descriptors against pinned paths. Call Connect to do
+// that, so a misconfigured gadget fails loudly there rather than on the
+// first report sent to the wrong endpoint.
+func NewComposite(config CompositeConfig) (*Composite, error) {
+	functions, err := discoverGadgetFunctions()
+	if err != nil {
+		return nil, err
+	}
+
+	byDevicePath := make(map[string]gadgetFunction, len(functions))
+	for _, f := range functions {
+		byDevicePath[f.devicePath] = f
+	}
+
+	c := &Composite{roles: make(map[Role]roleDevice)}
+
+	for role, path := range config {
+		c.roles[role] = roleDevice{device: NewDevice(path), sysfsPath: byDevicePath[path].sysfsPath}
+	}
+
+	pinned := make(map[string]bool, len(config))
+	for _, path := range config {
+		pinned[path] = true
+	}
+
+	for _, f := range functions {
+		if pinned[f.devicePath] {
+			continue
+		}
+		desc, err := f.reportDesc()
+		if err != nil {
+			return nil, err
+		}
+		got, err := parseTopLevelUsage(desc)
+		if err != nil {
+			return nil, fmt.Errorf("hid: %s: %w", f.devicePath, err)
+		}
+
+		for _, role := range autoDetectRoles {
+			if _, assigned := c.roles[role]; assigned {
+				continue
+			}
+			if got == roleUsage[role] {
+				c.roles[role] = roleDevice{device: NewDevice(f.devicePath), sysfsPath: f.sysfsPath}
+				break
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// Connect validates and opens every device Composite was assigned a
+// role for: CheckDevice must pass, and, where the device's configfs
+// function directory is known, its report descriptor must still match
+// the role's expected usage (a gadget can be reconfigured after
+// NewComposite ran). It then calls Connect on each device. A mismatch
+// or a failed device returns an error naming the offending role rather
+// than routing reports to a misconfigured endpoint.
+func (c *Composite) Connect() error {
+	for role, rd := range c.roles {
+		if err := rd.device.CheckDevice(); err != nil {
+			return fmt.Errorf("hid: role %q: %w", role, err)
+		}
+
+		if rd.sysfsPath != "" {
+			desc, err := (gadgetFunction{sysfsPath: rd.sysfsPath}).reportDesc()
+			if err != nil {
+				return fmt.Errorf("hid: role %q: %w", role, err)
+			}
+			ok, err := matchesRole(desc, role)
+			if err != nil {
+				return fmt.Errorf("hid: role %q: %w", role, err)
+			}
+			if !ok {
+				return fmt.Errorf("hid: role %q: device's report descriptor does not match", role)
+			}
+		}
+
+		if err := rd.device.Connect(); err != nil {
+			return fmt.Errorf("hid: role %q: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// device returns the Device assigned to role, or an error if Composite
+// wasn't given one (neither discovered nor pinned via CompositeConfig).
+func (c *Composite) device(role Role) (*Device, error) {
+	rd, ok := c.roles[role]
+	if !ok {
+		return nil, fmt.Errorf("hid: no device assigned to role %q", role)
+	}
+	return rd.device, nil
+}
+
+// Keyboard returns a Keyboard backed by the device assigned RoleKeyboard.
+func (c *Composite) Keyboard() (*Keyboard, error) {
+	device, err := c.device(RoleKeyboard)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyboard(device), nil
+}
+
+// Mouse returns a relative-mode Mouse backed by the device assigned
+// RoleMouse.
+func (c *Composite) Mouse() (*Mouse, error) {
+	device, err := c.device(RoleMouse)
+	if err != nil {
+		return nil, err
+	}
+	return NewMouse(device), nil
+}
+
+// AbsolutePointer returns an absolute-mode Mouse, normalized to a
+// width x height surface, backed by the device assigned
+// RoleAbsolutePointer.
+func (c *Composite) AbsolutePointer(width, height int) (*Mouse, error) {
+	device, err := c.device(RoleAbsolutePointer)
+	if err != nil {
+		return nil, err
+	}
+	return NewMouseAbsolute(device, width, height), nil
+}
+
+// ConsumerControl returns a Consumer backed by the device assigned
+// RoleConsumerControl.
+func (c *Composite) ConsumerControl() (*Consumer, error) {
+	device, err := c.device(RoleConsumerControl)
+	if err != nil {
+		return nil, err
+	}
+	return NewConsumer(device), nil
+}
@@ -0,0 +1,93 @@
+package hid
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newFakeGadget builds a fake configfs tree under t.TempDir() with one
+// hid.* function directory per descriptor and points gadgetFunctionsGlob
+// at it for the duration of the test.
+func newFakeGadget(t *testing.T, descriptors ...[]byte) {
+	t.Helper()
+
+	root := filepath.Join(t.TempDir(), "usb_gadget", "g1", "functions")
+	for i, desc := range descriptors {
+		dir := filepath.Join(root, "hid."+string(rune('0'+i)))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create fake gadget function dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "report_desc"), desc, 0o644); err != nil {
+			t.Fatalf("failed to write fake report descriptor: %v", err)
+		}
+	}
+
+	original := gadgetFunctionsGlob
+	gadgetFunctionsGlob = filepath.Join(root, "hid.*")
+	t.Cleanup(func() { gadgetFunctionsGlob = original })
+}
+
+func TestNewCompositeAssignsRolesByDescriptor(t *testing.T) {
+	newFakeGadget(t, keyboardDescriptor(), mouseDescriptor(), consumerDescriptor())
+
+	c, err := NewComposite(nil)
+	if err != nil {
+		t.Fatalf("NewComposite() error = %v", err)
+	}
+
+	if _, err := c.Keyboard(); err != nil {
+		t.Errorf("Keyboard() error = %v, want nil", err)
+	}
+	if _, err := c.Mouse(); err != nil {
+		t.Errorf("Mouse() error = %v, want nil", err)
+	}
+	if _, err := c.ConsumerControl(); err != nil {
+		t.Errorf("ConsumerControl() error = %v, want nil", err)
+	}
+}
+
+func TestNewCompositeLeavesUnknownRolesUnassigned(t *testing.T) {
+	newFakeGadget(t, keyboardDescriptor())
+
+	c, err := NewComposite(nil)
+	if err != nil {
+		t.Fatalf("NewComposite() error = %v", err)
+	}
+
+	if _, err := c.Mouse(); err == nil {
+		t.Error("Mouse() with no mouse function discovered error = nil, want non-nil")
+	}
+}
+
+func TestNewCompositeNeverAutoAssignsAbsolutePointer(t *testing.T) {
+	newFakeGadget(t, mouseDescriptor())
+
+	c, err := NewComposite(nil)
+	if err != nil {
+		t.Fatalf("NewComposite() error = %v", err)
+	}
+
+	if _, err := c.Mouse(); err != nil {
+		t.Errorf("Mouse() error = %v, want nil", err)
+	}
+	if _, err := c.AbsolutePointer(1920, 1080); err == nil {
+		t.Error("AbsolutePointer() with no pinned config error = nil, want non-nil (ambiguous with Mouse)")
+	}
+}
+
+func TestNewCompositeConfigPinsAbsolutePointer(t *testing.T) {
+	newFakeGadget(t, mouseDescriptor())
+
+	c, err := NewComposite(CompositeConfig{RoleAbsolutePointer: "/dev/hidg5"})
+	if err != nil {
+		t.Fatalf("NewComposite() error = %v", err)
+	}
+
+	if _, err := c.AbsolutePointer(1920, 1080); err != nil {
+		t.Errorf("AbsolutePointer() error = %v, want nil", err)
+	}
+	if _, err := c.Mouse(); err != nil {
+		t.Errorf("Mouse() error = %v, want nil", err)
+	}
+}
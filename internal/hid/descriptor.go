@@ -0,0 +1,87 @@
+package hid
+
+import "fmt"
+
+// usagePageGenericDesktop and usagePageConsumer are the HID usage pages
+// a report descriptor's top-level collection declares for the roles
+// Composite cares about. See the USB HID Usage Tables specification.
+const (
+	usagePageGenericDesktop = 0x01
+	usagePageConsumer       = 0x0C
+)
+
+// Generic Desktop usages for the top-level collection.
+const (
+	usageMouse    = 0x02
+	usageKeyboard = 0x06
+)
+
+// Consumer usage for the top-level collection.
+const usageConsumerControl = 0x01
+
+// descriptorUsage is the usage page + usage declared on a report
+// descriptor's first top-level (Application) collection, which is what
+// a host uses to decide what kind of device a HID interface is.
+type descriptorUsage struct {
+	page  byte
+	usage byte
+}
+
+// parseTopLevelUsage walks a HID report descriptor far enough to find
+// the usage page and usage of its first Application collection, which
+// is sufficient to tell a keyboard function from a mouse or consumer
+// control function. It does not attempt to parse report fields, nested
+// collections, or long items (0xFE) — gadget descriptors in this
+// project never use them.
+func parseTopLevelUsage(desc []byte) (descriptorUsage, error) {
+	var page, usage byte
+	var haveUsage bool
+
+	i := 0
+	for i < len(desc) {
+		tag := desc[i]
+		size := int(tag & 0x03)
+		if size == 3 {
+			size = 4
+		}
+		if i+1+size > len(desc) {
+			return descriptorUsage{}, fmt.Errorf("hid: truncated report descriptor item at byte %d", i)
+		}
+		data := desc[i+1 : i+1+size]
+		i += 1 + size
+
+		switch tag &^ 0x03 {
+		case 0x04: // Usage Page (global item)
+			if len(data) > 0 {
+				page = data[0]
+			}
+		case 0x08: // Usage (local item)
+			if len(data) > 0 {
+				usage = data[0]
+				haveUsage = true
+			}
+		case 0xA0: // Collection
+			if haveUsage {
+				return descriptorUsage{page: page, usage: usage}, nil
+			}
+		}
+	}
+
+	return descriptorUsage{}, fmt.Errorf("hid: report descriptor has no top-level collection")
+}
+
+// matchesRole reports whether a report descriptor's top-level usage is
+// the one expected for role.
+func matchesRole(desc []byte, role Role) (bool, error) {
+	want, ok := roleUsage[role]
+	if !ok {
+		return false, fmt.Errorf("hid: unknown role %q", role)
+	}
+
+	got, err := parseTopLevelUsage(desc)
+	if err != nil {
+		return false, err
+	}
+
+	return got.page == want.page && got.usage == want.usage, nil
+}
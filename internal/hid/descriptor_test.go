@@ -0,0 +1,88 @@
+package hid
+
+import "testing"
+
+func keyboardDescriptor() []byte {
+	return []byte{
+		0x05, 0x01, // USAGE_PAGE (Generic Desktop)
+		0x09, 0x06, // USAGE (Keyboard)
+		0xA1, 0x01, // COLLECTION (Application)
+	}
+}
+
+func mouseDescriptor() []byte {
+	return []byte{
+		0x05, 0x01, // USAGE_PAGE (Generic Desktop)
+		0x09, 0x02, // USAGE (Mouse)
+		0xA1, 0x01, // COLLECTION (Application)
+	}
+}
+
+func consumerDescriptor() []byte {
+	return []byte{
+		0x05, 0x0C, // USAGE_PAGE (Consumer)
+		0x09, 0x01, // USAGE (Consumer Control)
+		0xA1, 0x01, // COLLECTION (Application)
+	}
+}
+
+func TestParseTopLevelUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		desc []byte
+		want descriptorUsage
+	}{
+		{name: "keyboard", desc: keyboardDescriptor(), want: descriptorUsage{page: 0x01, usage: 0x06}},
+		{name: "mouse", desc: mouseDescriptor(), want: descriptorUsage{page: 0x01, usage: 0x02}},
+		{name: "consumer control", desc: consumerDescriptor(), want: descriptorUsage{page: 0x0C, usage: 0x01}},
+		{
+			name: "extended mouse descriptor fragment from this package",
+			desc: MouseReportDescriptorExtended,
+			want: descriptorUsage{page: 0x01, usage: 0x02},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTopLevelUsage(tt.desc)
+			if err != nil {
+				t.Fatalf("parseTopLevelUsage() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseTopLevelUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTopLevelUsageNoCollection(t *testing.T) {
+	desc := []byte{0x05, 0x01, 0x09, 0x06} // usage page + usage, no COLLECTION
+	if _, err := parseTopLevelUsage(desc); err == nil {
+		t.Error("parseTopLevelUsage() with no collection error = nil, want non-nil")
+	}
+}
+
+func TestParseTopLevelUsageTruncated(t *testing.T) {
+	desc := []byte{0x05} // USAGE_PAGE tag with no data byte
+	if _, err := parseTopLevelUsage(desc); err == nil {
+		t.Error("parseTopLevelUsage() on truncated item error = nil, want non-nil")
+	}
+}
+
+func TestMatchesRole(t *testing.T) {
+	ok, err := matchesRole(keyboardDescriptor(), RoleKeyboard)
+	if err != nil {
+		t.Fatalf("matchesRole() error = %v", err)
+	}
+	if !ok {
+		t.Error("matchesRole(keyboardDescriptor, RoleKeyboard) = false, want true")
+	}
+
+	ok, err = matchesRole(keyboardDescriptor(), RoleMouse)
+	if err != nil {
+		t.Fatalf("matchesRole() error = %v", err)
+	}
+	if ok {
+		t.Error("matchesRole(keyboardDescriptor, RoleMouse) = true, want false")
+	}
+}
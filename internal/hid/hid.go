@@ -1,9 +1,22 @@
+//go:build linux && !hidapi
+
 // Package hid provides HID device interaction for keyboard and mouse control.
+//
+// This file is the default backend: it drives the Linux USB gadget
+// character device (/dev/hidgN) the kindavmd host itself exposes. A
+// second backend, selected with the "hidapi" build tag, drives a
+// physical USB HID device through hidapi instead, for controlling a
+// separate gadget host (e.g. another Pi Zero) from macOS or Windows;
+// see hidapi.go.
 package hid
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"log"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -13,9 +26,61 @@ const (
 	DefaultDelayMS   = 10
 )
 
-// Device represents a HID device interface
+// reportQueueSize bounds how many reports SendReport can get ahead of
+// the device write; once full, SendReport applies backpressure to the
+// caller rather than dropping input.
+const reportQueueSize = 256
+
+// readBufSize comfortably fits any input report this module parses
+// (a one-or-two-byte LED bitmap), with headroom for descriptors this
+// gadget doesn't yet model.
+const readBufSize = 64
+
+// subscriberQueueSize bounds how far a Subscribe channel (or ReadReport
+// caller) can lag behind the reader goroutine; once full, the oldest
+// buffered report is dropped in favor of the newest, since feedback
+// reports like LED state only matter for their latest value.
+const subscriberQueueSize = 8
+
+// queuedReport is either a report to write (data != nil) or a Flush
+// barrier (done != nil), queued in the order SendReport/Flush were
+// called so ordering is preserved end to end.
+type queuedReport struct {
+	data    []byte
+	delayMS int
+	done    chan struct{}
+}
+
+// Device represents a persistent HID device interface. Reports are
+// enqueued onto a buffered channel and written to the device by a
+// background ReportWriter goroutine, so SendReport doesn't block on
+// the (comparatively slow) device write; this matters when streaming
+// mouse motion or rapid keystrokes. The underlying file is opened on
+// first use via Connect and kept open for the lifetime of the Device,
+// rather than reopened on every report.
 type Device struct {
 	path string
+
+	mu   sync.Mutex
+	file *os.File
+
+	reports chan queuedReport
+	stop    chan struct{}
+	writeWg sync.WaitGroup
+
+	coalesceMu sync.Mutex
+	lastQueued []byte
+
+	// Input report reading, started lazily by ReadReport/Subscribe.
+	// Tracked with its own WaitGroup, separate from the writer's: the
+	// writer must finish draining before the file closes out from under
+	// it, while the reader just needs to notice stop on its own (see
+	// Close and readReports).
+	reading bool
+	reads   chan []byte
+	subsMu  sync.Mutex
+	subs    map[byte][]chan []byte
+	readWg  sync.WaitGroup
 }
 
 // NewDevice creates a new HID device interface
@@ -26,28 +91,309 @@ func NewDevice(path string) *Device {
 	return &Device{path: path}
 }
 
-// SendReport sends a HID report to the device
-func (d *Device) SendReport(report []byte, delayMS int) error {
+// Connect opens the HID device and starts the background ReportWriter
+// goroutine, if it isn't already running. SendReport calls Connect
+// itself, so most callers never need to call it directly; it's exposed
+// so a caller can fail fast (e.g. at startup) instead of on the first
+// report.
+func (d *Device) Connect() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file != nil {
+		return nil
+	}
+
 	f, err := os.OpenFile(d.path, os.O_RDWR, 0o666)
 	if err != nil {
 		return fmt.Errorf("failed to open HID device: %w", err)
 	}
-	defer func() {
-		_ = f.Close()
-	}()
 
-	_, err = f.Write(report)
-	if err != nil {
-		return fmt.Errorf("failed to write HID report: %w", err)
+	d.file = f
+	d.reports = make(chan queuedReport, reportQueueSize)
+	d.stop = make(chan struct{})
+
+	d.writeWg.Add(1)
+	go d.writeReports()
+
+	return nil
+}
+
+// Close stops the ReportWriter goroutine, after letting it finish
+// writing whatever was already queued, then closes the device handle,
+// then waits for the reader goroutine (if one was started) to notice.
+// The writer and reader are tracked with separate WaitGroups and the
+// file isn't cleared until both are done, so neither goroutine can
+// observe a nil or already-closed d.file out from under it. readReports
+// relies on its own read deadline to notice stop promptly; a device
+// whose driver doesn't support deadlines would leave it blocked in Read
+// until the next byte arrives, since closing the file here doesn't
+// interrupt a read already blocked on it.
+func (d *Device) Close() error {
+	d.mu.Lock()
+	if d.file == nil {
+		d.mu.Unlock()
+		return nil
+	}
+	file := d.file
+	d.reading = false
+	close(d.stop)
+	d.mu.Unlock()
+
+	d.writeWg.Wait()
+	err := file.Close()
+	d.readWg.Wait()
+
+	d.mu.Lock()
+	d.file = nil
+	d.mu.Unlock()
+
+	return err
+}
+
+// writeReports drains queued reports and writes them to the device, in
+// order, until stop is closed and the queue has been fully drained.
+func (d *Device) writeReports() {
+	defer d.writeWg.Done()
+
+	for {
+		select {
+		case report := <-d.reports:
+			d.writeReport(report)
+		case <-d.stop:
+			d.drain()
+			return
+		}
+	}
+}
+
+// drain writes every report left in the queue without blocking, so
+// Close doesn't discard reports that SendReport already accepted.
+func (d *Device) drain() {
+	for {
+		select {
+		case report := <-d.reports:
+			d.writeReport(report)
+		default:
+			return
+		}
+	}
+}
+
+// writeReport writes a single queued report to the device, or signals
+// a Flush barrier once every report ahead of it has been written.
+func (d *Device) writeReport(report queuedReport) {
+	if report.done != nil {
+		close(report.done)
+		return
+	}
+
+	if _, err := d.file.Write(report.data); err != nil {
+		log.Printf("failed to write HID report: %v", err)
+		return
+	}
+
+	if report.delayMS > 0 {
+		time.Sleep(time.Duration(report.delayMS) * time.Millisecond)
+	}
+}
+
+// SendReport enqueues a HID report for the background ReportWriter to
+// write, connecting the device first if needed. Consecutive identical
+// reports (e.g. repeated mouse-move reports with no actual movement)
+// are coalesced into one, since resending the same report conveys no
+// new HID state; this keeps a held, stationary mouse button from
+// flooding the queue. A write failure is logged rather than returned,
+// since by the time it happens the caller has already moved on.
+func (d *Device) SendReport(report []byte, delayMS int) error {
+	if err := d.Connect(); err != nil {
+		return err
+	}
+
+	d.coalesceMu.Lock()
+	if bytes.Equal(report, d.lastQueued) {
+		d.coalesceMu.Unlock()
+		return nil
+	}
+	d.lastQueued = append([]byte(nil), report...)
+	d.coalesceMu.Unlock()
+
+	d.reports <- queuedReport{data: report, delayMS: delayMS}
+	return nil
+}
+
+// Flush blocks until every report enqueued so far has been written to
+// the device.
+func (d *Device) Flush() error {
+	d.mu.Lock()
+	reports := d.reports
+	d.mu.Unlock()
+
+	if reports == nil {
+		return nil
 	}
 
-	if delayMS > 0 {
-		time.Sleep(time.Duration(delayMS) * time.Millisecond)
+	done := make(chan struct{})
+	reports <- queuedReport{done: done}
+	<-done
+	return nil
+}
+
+// startReading connects the device and starts the background reader
+// goroutine, if it isn't already running. Called by ReadReport and
+// Subscribe, so most callers never need to think about it.
+func (d *Device) startReading() error {
+	if err := d.Connect(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.reading {
+		return nil
 	}
+	d.reading = true
+	d.reads = make(chan []byte, subscriberQueueSize)
+	d.subs = make(map[byte][]chan []byte)
+
+	d.readWg.Add(1)
+	go d.readReports()
 
 	return nil
 }
 
+// readReports reads input reports from the device and fans each one
+// out to ReadReport and to every Subscribe channel registered for its
+// report ID, until stop is closed. It polls stop on a read deadline;
+// real device nodes and the package's own test fixtures are both opened
+// non-blocking, so SetReadDeadline is expected to succeed in practice,
+// but a driver that doesn't support it falls back to a plain blocking
+// Read, which only returns once the next report actually arrives.
+func (d *Device) readReports() {
+	defer d.readWg.Done()
+	defer d.closeReaders()
+
+	buf := make([]byte, readBufSize)
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		if err := d.file.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+			// Not every character device driver supports deadlines; fall
+			// back to a plain blocking Read, which Close cannot interrupt:
+			// it only returns once the next report arrives.
+		}
+
+		n, err := d.file.Read(buf)
+		if err != nil {
+			if os.IsTimeout(err) {
+				continue
+			}
+			return
+		}
+		if n == 0 {
+			continue
+		}
+
+		d.dispatch(append([]byte(nil), buf[:n]...))
+	}
+}
+
+// dispatch fans report out to ReadReport callers and to any Subscribe
+// channel registered for its report ID (the first byte, for a
+// descriptor with multiple top-level collections sharing one device
+// node), dropping the oldest buffered report for a channel that isn't
+// keeping up rather than blocking the reader.
+func (d *Device) dispatch(report []byte) {
+	sendNonBlocking(d.reads, report)
+
+	if len(report) == 0 {
+		return
+	}
+
+	d.subsMu.Lock()
+	subs := append([]chan []byte(nil), d.subs[report[0]]...)
+	d.subsMu.Unlock()
+
+	for _, ch := range subs {
+		sendNonBlocking(ch, report)
+	}
+}
+
+// sendNonBlocking sends report on ch, dropping the oldest queued report
+// to make room if ch is full.
+func sendNonBlocking(ch chan []byte, report []byte) {
+	select {
+	case ch <- report:
+	default:
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- report:
+		default:
+		}
+	}
+}
+
+// closeReaders closes the ReadReport fan-in channel and every
+// registered Subscribe channel, signaling every caller that the reader
+// has stopped.
+func (d *Device) closeReaders() {
+	close(d.reads)
+
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+	for _, chans := range d.subs {
+		for _, ch := range chans {
+			close(ch)
+		}
+	}
+	d.subs = nil
+}
+
+// ReadReport blocks until the next input report arrives from the
+// device, of any report ID, or ctx is canceled.
+func (d *Device) ReadReport(ctx context.Context) ([]byte, error) {
+	if err := d.startReading(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case report, ok := <-d.reads:
+		if !ok {
+			return nil, fmt.Errorf("device closed")
+		}
+		return report, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Subscribe returns a channel that receives every subsequent input
+// report with the given report ID (the report's first byte), such as
+// the keyboard LED output report alongside a separate consumer-control
+// feedback report on the same device node. The channel is closed if
+// the device is closed.
+func (d *Device) Subscribe(reportID byte) (<-chan []byte, error) {
+	if err := d.startReading(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []byte, subscriberQueueSize)
+
+	d.subsMu.Lock()
+	d.subs[reportID] = append(d.subs[reportID], ch)
+	d.subsMu.Unlock()
+
+	return ch, nil
+}
+
 // CheckDevice verifies that the HID device is available
 func (d *Device) CheckDevice() error {
 	info, err := os.Stat(d.path)
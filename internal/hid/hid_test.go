@@ -0,0 +1,311 @@
+//go:build linux && !hidapi
+
+package hid
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newTestDevice returns a Device wired up to an in-memory pipe instead
+// of a real character device, with its ReportWriter goroutine already
+// running, plus the read end of the pipe for assertions.
+func newTestDevice(t *testing.T) (*Device, *os.File) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+
+	d := &Device{
+		path:    "unused",
+		file:    w,
+		reports: make(chan queuedReport, reportQueueSize),
+		stop:    make(chan struct{}),
+	}
+	d.writeWg.Add(1)
+	go d.writeReports()
+
+	return d, r
+}
+
+// newDuplexTestDevice returns a Device wired up to one end of a unix
+// socketpair, with the other end returned for the test to write input
+// reports into (a plain os.Pipe only supports writes from the Device's
+// side, but ReadReport/Subscribe need the Device's side to also read).
+func newDuplexTestDevice(t *testing.T) (*Device, *os.File) {
+	t.Helper()
+
+	// SEQPACKET, not STREAM, so that two back-to-back writes can't be
+	// coalesced into a single Read the way a real hidg report device
+	// never would (each Read there returns exactly one queued report).
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET, 0)
+	if err != nil {
+		t.Fatalf("failed to create socketpair: %v", err)
+	}
+	// Socketpair fds come back in blocking mode, which os.NewFile won't
+	// register with the runtime poller (it only does so for fds that are
+	// already non-blocking), so SetReadDeadline would silently fail below
+	// and readReports would fall onto its plain-blocking-Read path - where
+	// it can never be unblocked by Close, since closing an fd doesn't
+	// interrupt a read already blocked on it in the same process. Real
+	// device nodes are opened through os.OpenFile, which does this for us.
+	if err := syscall.SetNonblock(fds[0], true); err != nil {
+		t.Fatalf("failed to set device end non-blocking: %v", err)
+	}
+	devEnd := os.NewFile(uintptr(fds[0]), "device")
+	testEnd := os.NewFile(uintptr(fds[1]), "test")
+	t.Cleanup(func() {
+		_ = testEnd.Close()
+	})
+
+	d := &Device{
+		path:    "unused",
+		file:    devEnd,
+		reports: make(chan queuedReport, reportQueueSize),
+		stop:    make(chan struct{}),
+	}
+	d.writeWg.Add(1)
+	go d.writeReports()
+
+	return d, testEnd
+}
+
+// readReport reads exactly n bytes from r, failing the test if they
+// don't arrive within a short timeout.
+func readReport(t *testing.T, r *os.File, n int) []byte {
+	t.Helper()
+
+	buf := make([]byte, n)
+	if err := r.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("failed to set read deadline: %v", err)
+	}
+	if _, err := r.Read(buf); err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	return buf
+}
+
+func TestSendReportWritesToDevice(t *testing.T) {
+	d, r := newTestDevice(t)
+
+	report := []byte{0x04, 0x01, 0x0A, 0x00, 0x00}
+	if err := d.SendReport(report, 0); err != nil {
+		t.Fatalf("SendReport() error = %v", err)
+	}
+
+	got := readReport(t, r, len(report))
+	if !bytes.Equal(got, report) {
+		t.Errorf("device received %v, want %v", got, report)
+	}
+}
+
+func TestSendReportPreservesOrdering(t *testing.T) {
+	d, r := newTestDevice(t)
+
+	reports := [][]byte{
+		{0x04, 0x00, 0x01, 0x00, 0x00},
+		{0x04, 0x00, 0x02, 0x00, 0x00},
+		{0x04, 0x00, 0x03, 0x00, 0x00},
+	}
+	for _, report := range reports {
+		if err := d.SendReport(report, 0); err != nil {
+			t.Fatalf("SendReport() error = %v", err)
+		}
+	}
+
+	for _, want := range reports {
+		got := readReport(t, r, len(want))
+		if !bytes.Equal(got, want) {
+			t.Errorf("device received %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSendReportCoalescesConsecutiveIdentical(t *testing.T) {
+	d, r := newTestDevice(t)
+
+	stationary := []byte{0x04, 0x00, 0x00, 0x00, 0x00}
+	moved := []byte{0x04, 0x00, 0x05, 0x00, 0x00}
+
+	// Three consecutive identical reports should coalesce into one...
+	for i := 0; i < 3; i++ {
+		if err := d.SendReport(stationary, 0); err != nil {
+			t.Fatalf("SendReport() error = %v", err)
+		}
+	}
+	// ...followed by a distinct report, which must not be coalesced away.
+	if err := d.SendReport(moved, 0); err != nil {
+		t.Fatalf("SendReport() error = %v", err)
+	}
+
+	got := readReport(t, r, len(stationary))
+	if !bytes.Equal(got, stationary) {
+		t.Errorf("first device write = %v, want %v", got, stationary)
+	}
+	got = readReport(t, r, len(moved))
+	if !bytes.Equal(got, moved) {
+		t.Errorf("second device write = %v, want %v", got, moved)
+	}
+
+	// Nothing else should have been written (the duplicates were coalesced).
+	if err := r.SetReadDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatalf("failed to set read deadline: %v", err)
+	}
+	buf := make([]byte, 1)
+	if _, err := r.Read(buf); err == nil {
+		t.Errorf("unexpected extra write to device: %v", buf)
+	}
+}
+
+func TestFlushWaitsForQueueDrain(t *testing.T) {
+	d, r := newTestDevice(t)
+
+	report := []byte{0x04, 0x00, 0x00, 0x00, 0x00}
+	if err := d.SendReport(report, 0); err != nil {
+		t.Fatalf("SendReport() error = %v", err)
+	}
+
+	if err := d.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	// By the time Flush returns, the report must already be on the wire.
+	if err := r.SetReadDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatalf("failed to set read deadline: %v", err)
+	}
+	got := make([]byte, len(report))
+	if _, err := r.Read(got); err != nil {
+		t.Fatalf("expected report to have been written before Flush returned: %v", err)
+	}
+	if !bytes.Equal(got, report) {
+		t.Errorf("device received %v, want %v", got, report)
+	}
+}
+
+func TestFlushWithNothingQueued(t *testing.T) {
+	d := NewDevice("")
+	if err := d.Flush(); err != nil {
+		t.Errorf("Flush() on an unconnected device error = %v, want nil", err)
+	}
+}
+
+func TestCloseDrainsQueuedReports(t *testing.T) {
+	d, r := newTestDevice(t)
+
+	report := []byte{0x04, 0x00, 0x00, 0x00, 0x00}
+	if err := d.SendReport(report, 0); err != nil {
+		t.Fatalf("SendReport() error = %v", err)
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	got := readReport(t, r, len(report))
+	if !bytes.Equal(got, report) {
+		t.Errorf("device received %v, want %v", got, report)
+	}
+}
+
+func TestReadReportReturnsInputReport(t *testing.T) {
+	d, testEnd := newDuplexTestDevice(t)
+	t.Cleanup(func() { _ = d.Close() })
+
+	report := []byte{0x01, 0x02}
+	if _, err := testEnd.Write(report); err != nil {
+		t.Fatalf("failed to write input report: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	got, err := d.ReadReport(ctx)
+	if err != nil {
+		t.Fatalf("ReadReport() error = %v", err)
+	}
+	if !bytes.Equal(got, report) {
+		t.Errorf("ReadReport() = %v, want %v", got, report)
+	}
+}
+
+func TestReadReportCanceledByContext(t *testing.T) {
+	d, _ := newDuplexTestDevice(t)
+	t.Cleanup(func() { _ = d.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := d.ReadReport(ctx); err == nil {
+		t.Error("ReadReport() with a canceled context error = nil, want non-nil")
+	}
+}
+
+func TestSubscribeDemultiplexesByReportID(t *testing.T) {
+	d, testEnd := newDuplexTestDevice(t)
+	t.Cleanup(func() { _ = d.Close() })
+
+	ledReports, err := d.Subscribe(0x01)
+	if err != nil {
+		t.Fatalf("Subscribe(0x01) error = %v", err)
+	}
+	consumerReports, err := d.Subscribe(0x02)
+	if err != nil {
+		t.Fatalf("Subscribe(0x02) error = %v", err)
+	}
+
+	if _, err := testEnd.Write([]byte{0x02, 0xAA}); err != nil {
+		t.Fatalf("failed to write input report: %v", err)
+	}
+	if _, err := testEnd.Write([]byte{0x01, 0x03}); err != nil {
+		t.Fatalf("failed to write input report: %v", err)
+	}
+
+	select {
+	case got := <-ledReports:
+		if !bytes.Equal(got, []byte{0x01, 0x03}) {
+			t.Errorf("ledReports got %v, want %v", got, []byte{0x01, 0x03})
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for report on ledReports")
+	}
+
+	select {
+	case got := <-consumerReports:
+		if !bytes.Equal(got, []byte{0x02, 0xAA}) {
+			t.Errorf("consumerReports got %v, want %v", got, []byte{0x02, 0xAA})
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for report on consumerReports")
+	}
+}
+
+func TestCloseClosesSubscribers(t *testing.T) {
+	d, _ := newDuplexTestDevice(t)
+
+	ch, err := d.Subscribe(0x01)
+	if err != nil {
+		t.Fatalf("Subscribe(0x01) error = %v", err)
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("expected subscriber channel to be closed after Close()")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for subscriber channel to close")
+	}
+}
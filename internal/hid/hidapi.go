@@ -0,0 +1,386 @@
+//go:build hidapi
+
+// Package hid, in this build, drives a physical USB HID device through
+// hidapi (https://github.com/libusb/hidapi) rather than the local
+// /dev/hidgN gadget character device, so the control host can be a
+// separate machine — e.g. a macOS or Windows workstation driving a Pi
+// Zero HID gadget connected over USB. Select this backend with
+// `go build -tags hidapi`; it links against a system-installed
+// libhidapi via cgo (LDFLAGS below) rather than vendoring
+// hidapi's C sources, to keep this module's own build simple.
+package hid
+
+/*
+#cgo linux LDFLAGS: -lhidapi-hidraw
+#cgo darwin LDFLAGS: -lhidapi
+#cgo windows LDFLAGS: -lhidapi
+#include <hidapi/hidapi.h>
+#include <stdlib.h>
+#include <wchar.h>
+
+// hid_wstr_to_utf8 converts a hidapi wchar_t string (manufacturer,
+// product, or serial number) to a malloc'd UTF-8 C string using the
+// process locale, since hidapi has no built-in UTF-8 accessor. Callers
+// must free the result. Returns NULL for a NULL input.
+static char *hid_wstr_to_utf8(const wchar_t *ws) {
+	if (ws == NULL) {
+		return NULL;
+	}
+	size_t n = wcstombs(NULL, ws, 0);
+	if (n == (size_t)-1) {
+		return NULL;
+	}
+	char *buf = malloc(n + 1);
+	if (buf == NULL) {
+		return NULL;
+	}
+	wcstombs(buf, ws, n + 1);
+	return buf;
+}
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+func init() {
+	if C.hid_init() != 0 {
+		panic("hid: hidapi_init failed")
+	}
+}
+
+// ErrUnsupportedPlatform exists for API symmetry with the default
+// (non-hidapi) build; this build never returns it.
+var ErrUnsupportedPlatform = fmt.Errorf("hid: hidapi backend not built in (rebuild with -tags hidapi)")
+
+// Supported reports whether this binary was built with the hidapi
+// backend. Always true in this build.
+func Supported() bool {
+	return true
+}
+
+// DeviceInfo describes one HID device found by Enumerate.
+type DeviceInfo struct {
+	Path         string
+	VendorID     uint16
+	ProductID    uint16
+	Manufacturer string
+	Product      string
+	SerialNumber string
+}
+
+// Enumerate lists connected HID devices matching vendorID and
+// productID; either may be 0 as a wildcard.
+func Enumerate(vendorID, productID uint16) ([]DeviceInfo, error) {
+	head := C.hid_enumerate(C.ushort(vendorID), C.ushort(productID))
+	if head == nil {
+		return nil, nil
+	}
+	defer C.hid_free_enumeration(head)
+
+	var infos []DeviceInfo
+	for cur := head; cur != nil; cur = cur.next {
+		infos = append(infos, DeviceInfo{
+			Path:         C.GoString(cur.path),
+			VendorID:     uint16(cur.vendor_id),
+			ProductID:    uint16(cur.product_id),
+			Manufacturer: wcharToString(cur.manufacturer_string),
+			Product:      wcharToString(cur.product_string),
+			SerialNumber: wcharToString(cur.serial_number),
+		})
+	}
+	return infos, nil
+}
+
+// wcharToString converts a hidapi wchar_t* field to a Go string,
+// treating a conversion failure (e.g. a string the process locale
+// can't represent) as empty rather than an error, since it's cosmetic
+// metadata rather than something callers act on.
+func wcharToString(ws *C.wchar_t) string {
+	cstr := C.hid_wstr_to_utf8(ws)
+	if cstr == nil {
+		return ""
+	}
+	defer C.free(unsafe.Pointer(cstr))
+	return C.GoString(cstr)
+}
+
+// readBufSize comfortably fits any input report this module parses.
+const readBufSize = 64
+
+// subscriberQueueSize bounds how far a Subscribe channel (or ReadReport
+// caller) can lag behind the reader goroutine; once full, the oldest
+// buffered report is dropped in favor of the newest.
+const subscriberQueueSize = 8
+
+// Device is a HID device reached through hidapi, identified by a
+// hidapi device path (as returned by Enumerate) rather than a Linux
+// /dev/hidgN character device node.
+type Device struct {
+	path string
+
+	mu     sync.Mutex
+	handle *C.hid_device
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	reading bool
+	reads   chan []byte
+	subsMu  sync.Mutex
+	subs    map[byte][]chan []byte
+}
+
+// NewDevice creates a new HID device interface for the given hidapi
+// device path (see Enumerate). Prefer NewDeviceByVIDPID when you know
+// the device's vendor/product ID but not its path.
+func NewDevice(path string) *Device {
+	return &Device{path: path}
+}
+
+// NewDeviceByVIDPID enumerates connected HID devices and returns one
+// backed by the first match for vendorID/productID.
+func NewDeviceByVIDPID(vendorID, productID uint16) (*Device, error) {
+	devices, err := Enumerate(vendorID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("hid: no device found for vendor 0x%04x product 0x%04x", vendorID, productID)
+	}
+	return NewDevice(devices[0].Path), nil
+}
+
+// Connect opens the hidapi device, if it isn't already open.
+func (d *Device) Connect() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.handle != nil {
+		return nil
+	}
+
+	cpath := C.CString(d.path)
+	defer C.free(unsafe.Pointer(cpath))
+
+	handle := C.hid_open_path(cpath)
+	if handle == nil {
+		return fmt.Errorf("hid: failed to open device %s", d.path)
+	}
+
+	d.handle = handle
+	d.stop = make(chan struct{})
+	return nil
+}
+
+// Close closes the device and stops the background reader goroutine,
+// if one is running.
+func (d *Device) Close() error {
+	d.mu.Lock()
+	if d.handle == nil {
+		d.mu.Unlock()
+		return nil
+	}
+	handle := d.handle
+	d.handle = nil
+	d.reading = false
+	close(d.stop)
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	C.hid_close(handle)
+	return nil
+}
+
+// SendReport writes a HID output report to the device. delayMS is
+// accepted for API compatibility with the gadget backend's SendReport,
+// which uses it to throttle a background write queue; hidapi's
+// hid_write is a direct synchronous call, so there is no queue to
+// throttle and delayMS is ignored.
+func (d *Device) SendReport(report []byte, delayMS int) error {
+	if err := d.Connect(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	handle := d.handle
+	d.mu.Unlock()
+
+	cdata := C.CBytes(report)
+	defer C.free(cdata)
+
+	n := C.hid_write(handle, (*C.uchar)(cdata), C.size_t(len(report)))
+	if n < 0 {
+		return fmt.Errorf("hid: failed to write report to %s", d.path)
+	}
+	return nil
+}
+
+// Flush is a no-op in this backend: SendReport writes synchronously, so
+// there is nothing queued to wait on.
+func (d *Device) Flush() error {
+	return nil
+}
+
+// CheckDevice verifies that a device matching this Device's path is
+// still enumerable.
+func (d *Device) CheckDevice() error {
+	cpath := C.CString(d.path)
+	defer C.free(unsafe.Pointer(cpath))
+
+	handle := C.hid_open_path(cpath)
+	if handle == nil {
+		return fmt.Errorf("hid: device not found: %s", d.path)
+	}
+	C.hid_close(handle)
+	return nil
+}
+
+// startReading connects the device and starts the background reader
+// goroutine, if it isn't already running.
+func (d *Device) startReading() error {
+	if err := d.Connect(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.reading {
+		return nil
+	}
+	d.reading = true
+	d.reads = make(chan []byte, subscriberQueueSize)
+	d.subs = make(map[byte][]chan []byte)
+
+	d.wg.Add(1)
+	go d.readReports()
+
+	return nil
+}
+
+// readReports polls hid_read_timeout for input reports until stop is
+// closed, fanning each one out via dispatch.
+func (d *Device) readReports() {
+	defer d.wg.Done()
+	defer d.closeReaders()
+
+	buf := make([]byte, readBufSize)
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		d.mu.Lock()
+		handle := d.handle
+		d.mu.Unlock()
+		if handle == nil {
+			return
+		}
+
+		n := C.hid_read_timeout(handle, (*C.uchar)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)), 1000)
+		if n < 0 {
+			return
+		}
+		if n == 0 {
+			continue
+		}
+
+		d.dispatch(append([]byte(nil), buf[:n]...))
+	}
+}
+
+// dispatch fans report out to ReadReport callers and to any Subscribe
+// channel registered for its report ID (the first byte), dropping the
+// oldest buffered report for a channel that isn't keeping up rather
+// than blocking the reader.
+func (d *Device) dispatch(report []byte) {
+	sendNonBlocking(d.reads, report)
+
+	if len(report) == 0 {
+		return
+	}
+
+	d.subsMu.Lock()
+	subs := append([]chan []byte(nil), d.subs[report[0]]...)
+	d.subsMu.Unlock()
+
+	for _, ch := range subs {
+		sendNonBlocking(ch, report)
+	}
+}
+
+// sendNonBlocking sends report on ch, dropping the oldest queued report
+// to make room if ch is full.
+func sendNonBlocking(ch chan []byte, report []byte) {
+	select {
+	case ch <- report:
+	default:
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- report:
+		default:
+		}
+	}
+}
+
+// closeReaders closes the ReadReport fan-in channel and every
+// registered Subscribe channel, signaling every caller that the reader
+// has stopped.
+func (d *Device) closeReaders() {
+	close(d.reads)
+
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+	for _, chans := range d.subs {
+		for _, ch := range chans {
+			close(ch)
+		}
+	}
+	d.subs = nil
+}
+
+// ReadReport blocks until the next input report arrives from the
+// device, of any report ID, or ctx is canceled.
+func (d *Device) ReadReport(ctx context.Context) ([]byte, error) {
+	if err := d.startReading(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case report, ok := <-d.reads:
+		if !ok {
+			return nil, fmt.Errorf("device closed")
+		}
+		return report, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Subscribe returns a channel that receives every subsequent input
+// report with the given report ID (the report's first byte). The
+// channel is closed if the device is closed.
+func (d *Device) Subscribe(reportID byte) (<-chan []byte, error) {
+	if err := d.startReading(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []byte, subscriberQueueSize)
+
+	d.subsMu.Lock()
+	d.subs[reportID] = append(d.subs[reportID], ch)
+	d.subsMu.Unlock()
+
+	return ch, nil
+}
@@ -0,0 +1,42 @@
+//go:build linux && !hidapi
+
+package hid
+
+import "errors"
+
+// ErrUnsupportedPlatform is returned by NewDeviceByVIDPID and Enumerate
+// when this binary was built without the hidapi backend. Rebuild with
+// -tags hidapi (and a libhidapi install available to cgo) to drive a
+// physical USB HID device instead of the local /dev/hidgN gadget.
+var ErrUnsupportedPlatform = errors.New("hid: hidapi backend not built in (rebuild with -tags hidapi)")
+
+// Supported reports whether this binary was built with the hidapi
+// backend (build tag "hidapi"). The default Linux gadget build always
+// returns false here; see hidapi.go for the build that returns true.
+func Supported() bool {
+	return false
+}
+
+// DeviceInfo describes one HID device found by Enumerate. Declared in
+// both backends so callers can range over the result without a build
+// tag of their own.
+type DeviceInfo struct {
+	Path         string
+	VendorID     uint16
+	ProductID    uint16
+	Manufacturer string
+	Product      string
+	SerialNumber string
+}
+
+// Enumerate always returns ErrUnsupportedPlatform in this build; see
+// Supported.
+func Enumerate(vendorID, productID uint16) ([]DeviceInfo, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+// NewDeviceByVIDPID always returns ErrUnsupportedPlatform in this
+// build; see Supported.
+func NewDeviceByVIDPID(vendorID, productID uint16) (*Device, error) {
+	return nil, ErrUnsupportedPlatform
+}
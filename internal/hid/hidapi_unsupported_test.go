@@ -0,0 +1,23 @@
+//go:build linux && !hidapi
+
+package hid
+
+import "testing"
+
+func TestSupportedFalseWithoutHidapiTag(t *testing.T) {
+	if Supported() {
+		t.Error("Supported() = true, want false in the default (non-hidapi) build")
+	}
+}
+
+func TestEnumerateReturnsErrUnsupportedPlatform(t *testing.T) {
+	if _, err := Enumerate(0, 0); err != ErrUnsupportedPlatform {
+		t.Errorf("Enumerate() error = %v, want ErrUnsupportedPlatform", err)
+	}
+}
+
+func TestNewDeviceByVIDPIDReturnsErrUnsupportedPlatform(t *testing.T) {
+	if _, err := NewDeviceByVIDPID(0x1234, 0x5678); err != ErrUnsupportedPlatform {
+		t.Errorf("NewDeviceByVIDPID() error = %v, want ErrUnsupportedPlatform", err)
+	}
+}
@@ -1,5 +1,11 @@
 package hid
 
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
 // Keyboard modifier bits
 const (
 	ModNone       = 0x00
@@ -161,14 +167,40 @@ var BrowserKeyCodeMap = map[string]byte{
 	"CapsLock":      0x39,
 }
 
-// Keyboard represents a HID keyboard interface
+// ErrRollover is returned when a report or KeyDown call would require
+// more than six simultaneously-held non-modifier keys, which a standard
+// HID boot keyboard report cannot represent.
+type ErrRollover struct {
+	Count int
+}
+
+// Error implements the error interface
+func (e *ErrRollover) Error() string {
+	return fmt.Sprintf("cannot hold %d keys simultaneously: HID boot keyboard reports support at most 6", e.Count)
+}
+
+// Keyboard represents a HID keyboard interface. It tracks the set of
+// currently-held modifier bits and keycodes so that KeyDown/KeyUp can
+// emit incremental reports and ReleaseAll/WatchCancel can guarantee no
+// key or modifier is ever left latched on the guest.
 type Keyboard struct {
 	device *Device
+	layout Layout
+
+	mu       sync.Mutex
+	modifier byte
+	keycodes []byte
 }
 
 // NewKeyboard creates a new keyboard interface
 func NewKeyboard(device *Device) *Keyboard {
-	return &Keyboard{device: device}
+	return &Keyboard{device: device, layout: LayoutUSQWERTY}
+}
+
+// NewKeyboardWithLayout creates a new keyboard interface that uses the
+// given layout to encode runes passed to Type.
+func NewKeyboardWithLayout(device *Device, layout Layout) *Keyboard {
+	return &Keyboard{device: device, layout: layout}
 }
 
 // SendKeyReport sends a keyboard report with the given modifier and key codes
@@ -178,18 +210,186 @@ func NewKeyboard(device *Device) *Keyboard {
 //	Byte 1: Modifier keys
 //	Byte 2: Reserved (0x00)
 //	Bytes 3-8: Key codes (up to 6 simultaneous keys)
+//
+// More than six keycodes cannot be represented in a single report and
+// returns *ErrRollover rather than silently truncating.
 func (k *Keyboard) SendKeyReport(modifier byte, keycodes []byte) error {
+	if len(keycodes) > 6 {
+		return &ErrRollover{Count: len(keycodes)}
+	}
+
 	report := make([]byte, 9)
 	report[0] = 0x01 // Report ID for keyboard
 	report[1] = modifier
 	report[2] = 0x00 // Reserved
+	copy(report[3:], keycodes)
+
+	return k.device.SendReport(report, DefaultDelayMS)
+}
+
+// KeyDown presses a modifier and/or key, merging it into the currently
+// held state and emitting a report with everything that is now pressed.
+// A key of ModNone presses only the modifier. Pressing an already-held
+// key is idempotent. Holding a seventh distinct non-modifier key returns
+// *ErrRollover and leaves the existing state unchanged.
+func (k *Keyboard) KeyDown(modifier byte, key byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
 
-	// Copy up to 6 keycodes
-	for i := 0; i < 6 && i < len(keycodes); i++ {
-		report[3+i] = keycodes[i]
+	newModifier := k.modifier | modifier
+	newKeycodes := k.keycodes
+
+	if key != ModNone {
+		held := false
+		for _, kc := range k.keycodes {
+			if kc == key {
+				held = true
+				break
+			}
+		}
+		if !held {
+			if len(k.keycodes) >= 6 {
+				return &ErrRollover{Count: len(k.keycodes) + 1}
+			}
+			newKeycodes = append(append([]byte{}, k.keycodes...), key)
+		}
 	}
 
-	return k.device.SendReport(report, DefaultDelayMS)
+	if err := k.SendKeyReport(newModifier, newKeycodes); err != nil {
+		return err
+	}
+
+	k.modifier = newModifier
+	k.keycodes = newKeycodes
+	return nil
+}
+
+// KeyUp releases a single keycode, emitting a report with the remaining
+// held modifiers and keys. Releasing a key that isn't currently held is
+// a no-op.
+func (k *Keyboard) KeyUp(key byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	idx := -1
+	for i, kc := range k.keycodes {
+		if kc == key {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil
+	}
+
+	newKeycodes := append(append([]byte{}, k.keycodes[:idx]...), k.keycodes[idx+1:]...)
+	if err := k.SendKeyReport(k.modifier, newKeycodes); err != nil {
+		return err
+	}
+
+	k.keycodes = newKeycodes
+	return nil
+}
+
+// ModifierUp releases the given modifier bit(s), leaving other held
+// modifiers and keycodes untouched. Releasing a modifier that isn't
+// currently held is a no-op.
+func (k *Keyboard) ModifierUp(modifier byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	newModifier := k.modifier &^ modifier
+	if newModifier == k.modifier {
+		return nil
+	}
+
+	if err := k.SendKeyReport(newModifier, k.keycodes); err != nil {
+		return err
+	}
+
+	k.modifier = newModifier
+	return nil
+}
+
+// ReleaseAll emits a zeroed report and clears all tracked modifier and
+// key state, as if every key were released at once.
+func (k *Keyboard) ReleaseAll() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.SendKeyReport(0x00, nil); err != nil {
+		return err
+	}
+
+	k.modifier = 0
+	k.keycodes = nil
+	return nil
+}
+
+// WatchCancel starts a goroutine that calls ReleaseAll as soon as ctx is
+// canceled, so a dropped websocket from the browser client never leaves
+// Ctrl or Shift latched on the guest.
+func (k *Keyboard) WatchCancel(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		_ = k.ReleaseAll()
+	}()
+}
+
+// KeyboardLEDs is the parsed form of the keyboard output report the host
+// sends to reflect LED state (Num/Caps/Scroll Lock and, for layouts that
+// use them, Compose and Kana).
+type KeyboardLEDs struct {
+	Num     bool
+	Caps    bool
+	Scroll  bool
+	Compose bool
+	Kana    bool
+}
+
+// parseKeyboardLEDs decodes a keyboard output report's LED bitmap byte,
+// per the boot keyboard report descriptor (bit 0 Num Lock through bit 4
+// Kana).
+func parseKeyboardLEDs(bitmap byte) KeyboardLEDs {
+	return KeyboardLEDs{
+		Num:     bitmap&0x01 != 0,
+		Caps:    bitmap&0x02 != 0,
+		Scroll:  bitmap&0x04 != 0,
+		Compose: bitmap&0x08 != 0,
+		Kana:    bitmap&0x10 != 0,
+	}
+}
+
+// WatchLEDs subscribes to the keyboard's output report (Report ID 0x01)
+// and returns a channel of parsed LED state, one value per report the
+// host sends, so callers can reflect Num/Caps/Scroll Lock back to the
+// user. The channel is closed when the underlying device is closed.
+func (k *Keyboard) WatchLEDs() (<-chan KeyboardLEDs, error) {
+	reports, err := k.device.Subscribe(0x01)
+	if err != nil {
+		return nil, err
+	}
+
+	leds := make(chan KeyboardLEDs, subscriberQueueSize)
+	go func() {
+		defer close(leds)
+		for report := range reports {
+			if len(report) < 2 {
+				continue
+			}
+			select {
+			case leds <- parseKeyboardLEDs(report[1]):
+			default:
+				select {
+				case <-leds:
+				default:
+				}
+				leds <- parseKeyboardLEDs(report[1])
+			}
+		}
+	}()
+
+	return leds, nil
 }
 
 // PressKey sends a key press (key down)
@@ -210,6 +410,35 @@ func (k *Keyboard) SendKey(modifier byte, keycode byte) error {
 	return k.ReleaseKey()
 }
 
+// Type converts text into a sequence of key press/release reports using
+// the keyboard's configured layout. Runes that require a dead key are
+// sent as two separate press/release reports: the dead key, then the
+// base key. Returns *ErrUnsupportedRune for any rune the layout cannot
+// express.
+func (k *Keyboard) Type(text string) error {
+	for _, r := range text {
+		strokes, err := k.layout.Encode(r)
+		if err != nil {
+			return err
+		}
+
+		for _, stroke := range strokes {
+			// SendKey itself presses then releases, so consecutive
+			// strokes are naturally separated by a ReleaseKey report.
+			if err := k.SendKey(stroke.modifier, stroke.keycode); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SendCtrlC sends Ctrl-C key combination
+func (k *Keyboard) SendCtrlC() error {
+	return k.SendKey(ModLeftCtrl, BrowserKeyCodeMap["KeyC"])
+}
+
 // SendCtrlW sends Ctrl-W key combination
 func (k *Keyboard) SendCtrlW() error {
 	return k.SendKey(ModLeftCtrl, BrowserKeyCodeMap["KeyW"])
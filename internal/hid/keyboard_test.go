@@ -2,9 +2,25 @@ package hid
 
 import (
 	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
+func newTestKeyboard(t *testing.T) *Keyboard {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hidg0")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create fake HID device: %v", err)
+	}
+	_ = f.Close()
+	return NewKeyboard(NewDevice(path))
+}
+
 func TestBrowserKeyCodeMap(t *testing.T) {
 	tests := []struct {
 		code     string
@@ -124,6 +140,182 @@ func TestModifierConstants(t *testing.T) {
 	}
 }
 
+func TestLayoutUSQWERTYEncode(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        rune
+		modifier byte
+		keycode  byte
+	}{
+		{"lowercase a", 'a', ModNone, 0x04},
+		{"uppercase A", 'A', ModLeftShift, 0x04},
+		{"digit 1", '1', ModNone, 0x1E},
+		{"shifted 1 is exclamation", '!', ModLeftShift, 0x1E},
+		{"at sign", '@', ModLeftShift, 0x1F},
+		{"space", ' ', ModNone, 0x2C},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strokes, err := LayoutUSQWERTY.Encode(tt.r)
+			if err != nil {
+				t.Fatalf("Encode(%q) returned error: %v", tt.r, err)
+			}
+			if len(strokes) != 1 {
+				t.Fatalf("Encode(%q) = %d strokes, want 1", tt.r, len(strokes))
+			}
+			if strokes[0].modifier != tt.modifier || strokes[0].keycode != tt.keycode {
+				t.Errorf("Encode(%q) = {0x%02X, 0x%02X}, want {0x%02X, 0x%02X}",
+					tt.r, strokes[0].modifier, strokes[0].keycode, tt.modifier, tt.keycode)
+			}
+		})
+	}
+}
+
+func TestLayoutUnsupportedRune(t *testing.T) {
+	_, err := LayoutUSQWERTY.Encode('漢')
+	if err == nil {
+		t.Fatal("Encode('漢') expected an error, got nil")
+	}
+
+	var unsupported *ErrUnsupportedRune
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Encode('漢') error = %T, want *ErrUnsupportedRune", err)
+	}
+	if unsupported.Rune != '漢' {
+		t.Errorf("ErrUnsupportedRune.Rune = %q, want %q", unsupported.Rune, '漢')
+	}
+}
+
+func TestLayoutDEQWERTZSwapsYAndZ(t *testing.T) {
+	strokes, err := LayoutDEQWERTZ.Encode('z')
+	if err != nil {
+		t.Fatalf("Encode('z') returned error: %v", err)
+	}
+	if strokes[0].keycode != 0x1C {
+		t.Errorf("DE 'z' keycode = 0x%02X, want 0x1C (US 'y' position)", strokes[0].keycode)
+	}
+}
+
+func TestLayoutFRAZERTYDeadKey(t *testing.T) {
+	strokes, err := LayoutFRAZERTY.Encode('ê')
+	if err != nil {
+		t.Fatalf("Encode('ê') returned error: %v", err)
+	}
+	if len(strokes) != 2 {
+		t.Fatalf("Encode('ê') = %d strokes, want 2 (dead key + base)", len(strokes))
+	}
+	if !strokes[0].deadKey {
+		t.Errorf("Encode('ê') first stroke should be marked as a dead key")
+	}
+}
+
+func TestKeyboardConstructorLayouts(t *testing.T) {
+	kb := NewKeyboard(NewDevice(""))
+	strokes, err := kb.layout.Encode('z')
+	if err != nil || strokes[0].keycode != 0x1D {
+		t.Errorf("NewKeyboard should default to LayoutUSQWERTY ('z' = 0x1D), got strokes=%v err=%v", strokes, err)
+	}
+
+	withLayout := NewKeyboardWithLayout(NewDevice(""), LayoutDEQWERTZ)
+	strokes, err = withLayout.layout.Encode('z')
+	if err != nil || strokes[0].keycode != 0x1C {
+		t.Errorf("NewKeyboardWithLayout(LayoutDEQWERTZ) should use the DE layout ('z' = 0x1C), got strokes=%v err=%v", strokes, err)
+	}
+}
+
+func TestSendKeyReportRejectsRollover(t *testing.T) {
+	kb := newTestKeyboard(t)
+	err := kb.SendKeyReport(ModNone, []byte{0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A})
+
+	var rollover *ErrRollover
+	if !errors.As(err, &rollover) {
+		t.Fatalf("SendKeyReport with 7 keycodes error = %v, want *ErrRollover", err)
+	}
+	if rollover.Count != 7 {
+		t.Errorf("ErrRollover.Count = %d, want 7", rollover.Count)
+	}
+}
+
+func TestKeyDownIdempotent(t *testing.T) {
+	kb := newTestKeyboard(t)
+
+	if err := kb.KeyDown(ModNone, 0x04); err != nil {
+		t.Fatalf("KeyDown returned error: %v", err)
+	}
+	if err := kb.KeyDown(ModNone, 0x04); err != nil {
+		t.Fatalf("KeyDown (repeat) returned error: %v", err)
+	}
+
+	if len(kb.keycodes) != 1 {
+		t.Errorf("keycodes = %v, want a single 0x04 (idempotent press)", kb.keycodes)
+	}
+}
+
+func TestKeyUpUnpressedIsNoOp(t *testing.T) {
+	kb := newTestKeyboard(t)
+
+	if err := kb.KeyDown(ModNone, 0x04); err != nil {
+		t.Fatalf("KeyDown returned error: %v", err)
+	}
+	if err := kb.KeyUp(0x05); err != nil {
+		t.Fatalf("KeyUp on an unpressed key returned error: %v", err)
+	}
+
+	if len(kb.keycodes) != 1 || kb.keycodes[0] != 0x04 {
+		t.Errorf("keycodes = %v, want [0x04] unchanged", kb.keycodes)
+	}
+}
+
+func TestModifierUpClearsOnlySpecificBit(t *testing.T) {
+	kb := newTestKeyboard(t)
+
+	if err := kb.KeyDown(ModLeftCtrl|ModLeftShift, ModNone); err != nil {
+		t.Fatalf("KeyDown returned error: %v", err)
+	}
+	if err := kb.ModifierUp(ModLeftCtrl); err != nil {
+		t.Fatalf("ModifierUp returned error: %v", err)
+	}
+
+	if kb.modifier != ModLeftShift {
+		t.Errorf("modifier = 0x%02X, want 0x%02X (only Ctrl released)", kb.modifier, ModLeftShift)
+	}
+}
+
+func TestReleaseAllClearsState(t *testing.T) {
+	kb := newTestKeyboard(t)
+
+	if err := kb.KeyDown(ModLeftCtrl, 0x04); err != nil {
+		t.Fatalf("KeyDown returned error: %v", err)
+	}
+	if err := kb.ReleaseAll(); err != nil {
+		t.Fatalf("ReleaseAll returned error: %v", err)
+	}
+
+	if kb.modifier != 0 || len(kb.keycodes) != 0 {
+		t.Errorf("state after ReleaseAll = modifier=0x%02X keycodes=%v, want all cleared", kb.modifier, kb.keycodes)
+	}
+}
+
+func TestKeyDownConcurrentIsSerialized(t *testing.T) {
+	kb := newTestKeyboard(t)
+
+	var wg sync.WaitGroup
+	keys := []byte{0x04, 0x05, 0x06, 0x07, 0x08, 0x09}
+	for _, key := range keys {
+		wg.Add(1)
+		go func(k byte) {
+			defer wg.Done()
+			_ = kb.KeyDown(ModNone, k)
+		}(key)
+	}
+	wg.Wait()
+
+	if len(kb.keycodes) != len(keys) {
+		t.Errorf("keycodes = %v, want %d distinct keys held", kb.keycodes, len(keys))
+	}
+}
+
 func TestModifierCombinations(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -173,3 +365,53 @@ func TestModifierCombinations(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKeyboardLEDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		bitmap byte
+		want   KeyboardLEDs
+	}{
+		{name: "none", bitmap: 0x00, want: KeyboardLEDs{}},
+		{name: "num lock", bitmap: 0x01, want: KeyboardLEDs{Num: true}},
+		{name: "caps lock", bitmap: 0x02, want: KeyboardLEDs{Caps: true}},
+		{
+			name:   "all",
+			bitmap: 0x1F,
+			want:   KeyboardLEDs{Num: true, Caps: true, Scroll: true, Compose: true, Kana: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKeyboardLEDs(tt.bitmap); got != tt.want {
+				t.Errorf("parseKeyboardLEDs(0x%02X) = %+v, want %+v", tt.bitmap, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchLEDsReportsParsedState(t *testing.T) {
+	d, testEnd := newDuplexTestDevice(t)
+	t.Cleanup(func() { _ = d.Close() })
+	k := NewKeyboard(d)
+
+	leds, err := k.WatchLEDs()
+	if err != nil {
+		t.Fatalf("WatchLEDs() error = %v", err)
+	}
+
+	if _, err := testEnd.Write([]byte{0x01, 0x03}); err != nil {
+		t.Fatalf("failed to write LED report: %v", err)
+	}
+
+	select {
+	case got := <-leds:
+		want := KeyboardLEDs{Num: true, Caps: true}
+		if got != want {
+			t.Errorf("WatchLEDs() got = %+v, want %+v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for LED state")
+	}
+}
@@ -0,0 +1,250 @@
+package hid
+
+import "fmt"
+
+// keyStroke represents a single HID keypress needed to produce a rune:
+// the modifier bits and keycode to send, and an optional dead-key prefix
+// stroke that must be pressed and released before it.
+type keyStroke struct {
+	modifier byte
+	keycode  byte
+	deadKey  bool
+}
+
+// ErrUnsupportedRune is returned by Layout.Encode when a rune cannot be
+// expressed on the layout.
+type ErrUnsupportedRune struct {
+	Rune rune
+}
+
+// Error implements the error interface
+func (e *ErrUnsupportedRune) Error() string {
+	return fmt.Sprintf("rune %q is not supported by this keyboard layout", e.Rune)
+}
+
+// Layout converts runes into the sequence of HID key strokes needed to
+// type them on a given physical keyboard layout.
+type Layout interface {
+	// Encode returns the key strokes needed to produce r. Runes that
+	// require a dead key return two strokes: the dead key followed by
+	// the base key. Unsupported runes return *ErrUnsupportedRune.
+	Encode(r rune) ([]keyStroke, error)
+}
+
+// mapLayout is a Layout backed by a plain rune->keyStroke table, with an
+// optional table of dead-key sequences for runes that need them.
+type mapLayout struct {
+	direct   map[rune]keyStroke
+	deadKeys map[rune][2]keyStroke // dead key stroke, base key stroke
+}
+
+// Encode implements Layout
+func (l mapLayout) Encode(r rune) ([]keyStroke, error) {
+	if seq, ok := l.deadKeys[r]; ok {
+		return []keyStroke{seq[0], seq[1]}, nil
+	}
+	if ks, ok := l.direct[r]; ok {
+		return []keyStroke{ks}, nil
+	}
+	return nil, &ErrUnsupportedRune{Rune: r}
+}
+
+// key is a small helper for building mapLayout tables.
+func key(modifier, keycode byte) keyStroke {
+	return keyStroke{modifier: modifier, keycode: keycode}
+}
+
+func deadKey(modifier, keycode byte) keyStroke {
+	return keyStroke{modifier: modifier, keycode: keycode, deadKey: true}
+}
+
+// asciiLetters returns the direct (unshifted/shifted) mapping for a-z and
+// A-Z shared by every QWERTY-family layout; the physical keycode for each
+// letter is layout-specific so it is passed in by the caller.
+func asciiLetters(keycodeForLower map[rune]byte) map[rune]keyStroke {
+	m := make(map[rune]keyStroke, len(keycodeForLower)*2)
+	for r, code := range keycodeForLower {
+		m[r] = key(ModNone, code)
+		upper := r - ('a' - 'A')
+		m[upper] = key(ModLeftShift, code)
+	}
+	return m
+}
+
+// LayoutUSQWERTY is the standard US physical keyboard layout.
+var LayoutUSQWERTY = mapLayout{
+	direct: mergeRuneMaps(
+		asciiLetters(map[rune]byte{
+			'a': 0x04, 'b': 0x05, 'c': 0x06, 'd': 0x07, 'e': 0x08, 'f': 0x09,
+			'g': 0x0A, 'h': 0x0B, 'i': 0x0C, 'j': 0x0D, 'k': 0x0E, 'l': 0x0F,
+			'm': 0x10, 'n': 0x11, 'o': 0x12, 'p': 0x13, 'q': 0x14, 'r': 0x15,
+			's': 0x16, 't': 0x17, 'u': 0x18, 'v': 0x19, 'w': 0x1A, 'x': 0x1B,
+			'y': 0x1C, 'z': 0x1D,
+		}),
+		map[rune]keyStroke{
+			'1': key(ModNone, 0x1E), '!': key(ModLeftShift, 0x1E),
+			'2': key(ModNone, 0x1F), '@': key(ModLeftShift, 0x1F),
+			'3': key(ModNone, 0x20), '#': key(ModLeftShift, 0x20),
+			'4': key(ModNone, 0x21), '$': key(ModLeftShift, 0x21),
+			'5': key(ModNone, 0x22), '%': key(ModLeftShift, 0x22),
+			'6': key(ModNone, 0x23), '^': key(ModLeftShift, 0x23),
+			'7': key(ModNone, 0x24), '&': key(ModLeftShift, 0x24),
+			'8': key(ModNone, 0x25), '*': key(ModLeftShift, 0x25),
+			'9': key(ModNone, 0x26), '(': key(ModLeftShift, 0x26),
+			'0': key(ModNone, 0x27), ')': key(ModLeftShift, 0x27),
+			'\n': key(ModNone, 0x28),
+			' ':  key(ModNone, 0x2C),
+			'-':  key(ModNone, 0x2D), '_': key(ModLeftShift, 0x2D),
+			'=': key(ModNone, 0x2E), '+': key(ModLeftShift, 0x2E),
+			'[': key(ModNone, 0x2F), '{': key(ModLeftShift, 0x2F),
+			']': key(ModNone, 0x30), '}': key(ModLeftShift, 0x30),
+			'\\': key(ModNone, 0x31), '|': key(ModLeftShift, 0x31),
+			';': key(ModNone, 0x33), ':': key(ModLeftShift, 0x33),
+			'\'': key(ModNone, 0x34), '"': key(ModLeftShift, 0x34),
+			'`': key(ModNone, 0x35), '~': key(ModLeftShift, 0x35),
+			',': key(ModNone, 0x36), '<': key(ModLeftShift, 0x36),
+			'.': key(ModNone, 0x37), '>': key(ModLeftShift, 0x37),
+			'/': key(ModNone, 0x38), '?': key(ModLeftShift, 0x38),
+		},
+	),
+}
+
+// LayoutUKQWERTY is the UK physical keyboard layout: same key positions
+// as US QWERTY except for "@, \", £, ~, #, |" which sit on different keys.
+var LayoutUKQWERTY = mapLayout{
+	direct: mergeRuneMaps(
+		cloneRuneMap(LayoutUSQWERTY.direct),
+		map[rune]keyStroke{
+			'"': key(ModLeftShift, 0x1F), // Shift+2 on UK
+			'@': key(ModNone, 0x34),      // ' key on UK
+			'\'': key(ModNone, 0x34),
+			'£': key(ModLeftShift, 0x21), // Shift+4 on UK
+			'~': key(ModLeftShift, 0x32), // Shift+# on UK
+			'#': key(ModNone, 0x32),
+			'\\': key(ModNone, 0x64), // IntlBackslash on UK ISO layout
+			'|':  key(ModLeftShift, 0x64),
+		},
+	),
+}
+
+// LayoutDEQWERTZ is the German QWERTZ layout, notable for swapping Y/Z
+// and moving most symbols to AltGr combinations.
+var LayoutDEQWERTZ = mapLayout{
+	direct: mergeRuneMaps(
+		asciiLetters(map[rune]byte{
+			'a': 0x04, 'b': 0x05, 'c': 0x06, 'd': 0x07, 'e': 0x08, 'f': 0x09,
+			'g': 0x0A, 'h': 0x0B, 'i': 0x0C, 'j': 0x0D, 'k': 0x0E, 'l': 0x0F,
+			'm': 0x10, 'n': 0x11, 'o': 0x12, 'p': 0x13, 'q': 0x14, 'r': 0x15,
+			's': 0x16, 't': 0x17, 'u': 0x18, 'v': 0x19, 'w': 0x1A, 'x': 0x1B,
+			'z': 0x1C, // Y and Z swapped vs US
+			'y': 0x1D,
+		}),
+		map[rune]keyStroke{
+			'1': key(ModNone, 0x1E), '!': key(ModLeftShift, 0x1E),
+			'2': key(ModNone, 0x1F), '"': key(ModLeftShift, 0x1F),
+			'3': key(ModNone, 0x20), '§': key(ModLeftShift, 0x20),
+			'4': key(ModNone, 0x21), '$': key(ModLeftShift, 0x21),
+			'5': key(ModNone, 0x22), '%': key(ModLeftShift, 0x22),
+			'6': key(ModNone, 0x23), '&': key(ModLeftShift, 0x23),
+			'7': key(ModNone, 0x24), '/': key(ModLeftShift, 0x24),
+			'8': key(ModNone, 0x25), '(': key(ModLeftShift, 0x25),
+			'9': key(ModNone, 0x26), ')': key(ModLeftShift, 0x26),
+			'0': key(ModNone, 0x27), '=': key(ModLeftShift, 0x27),
+			'\n': key(ModNone, 0x28),
+			' ':  key(ModNone, 0x2C),
+			'ß':  key(ModNone, 0x2D), '?': key(ModLeftShift, 0x2D),
+			',': key(ModNone, 0x36), ';': key(ModLeftShift, 0x36),
+			'.': key(ModNone, 0x37), ':': key(ModLeftShift, 0x37),
+			'-': key(ModNone, 0x38), '_': key(ModLeftShift, 0x38),
+			'ü': key(ModNone, 0x2F), 'Ü': key(ModLeftShift, 0x2F),
+			'+': key(ModNone, 0x30), '*': key(ModLeftShift, 0x30),
+			'ö': key(ModNone, 0x33), 'Ö': key(ModLeftShift, 0x33),
+			'ä': key(ModNone, 0x34), 'Ä': key(ModLeftShift, 0x34),
+			'^': key(ModNone, 0x35), '°': key(ModLeftShift, 0x35),
+			'#': key(ModNone, 0x31), '\'': key(ModLeftShift, 0x31),
+			'<': key(ModNone, 0x64), '>': key(ModLeftShift, 0x64),
+		},
+	),
+}
+
+// LayoutFRAZERTY is the French AZERTY layout, notable for swapping
+// A/Q and Z/W and requiring Shift to type digits.
+var LayoutFRAZERTY = mapLayout{
+	direct: mergeRuneMaps(
+		map[rune]keyStroke{
+			'a': key(ModNone, 0x14), 'A': key(ModLeftShift, 0x14), // Q position
+			'b': key(ModNone, 0x05), 'B': key(ModLeftShift, 0x05),
+			'c': key(ModNone, 0x06), 'C': key(ModLeftShift, 0x06),
+			'd': key(ModNone, 0x07), 'D': key(ModLeftShift, 0x07),
+			'e': key(ModNone, 0x08), 'E': key(ModLeftShift, 0x08),
+			'f': key(ModNone, 0x09), 'F': key(ModLeftShift, 0x09),
+			'g': key(ModNone, 0x0A), 'G': key(ModLeftShift, 0x0A),
+			'h': key(ModNone, 0x0B), 'H': key(ModLeftShift, 0x0B),
+			'i': key(ModNone, 0x0C), 'I': key(ModLeftShift, 0x0C),
+			'j': key(ModNone, 0x0D), 'J': key(ModLeftShift, 0x0D),
+			'k': key(ModNone, 0x0E), 'K': key(ModLeftShift, 0x0E),
+			'l': key(ModNone, 0x0F), 'L': key(ModLeftShift, 0x0F),
+			'm': key(ModNone, 0x33), 'M': key(ModLeftShift, 0x33), // ; position
+			'n': key(ModNone, 0x11), 'N': key(ModLeftShift, 0x11),
+			'o': key(ModNone, 0x12), 'O': key(ModLeftShift, 0x12),
+			'p': key(ModNone, 0x13), 'P': key(ModLeftShift, 0x13),
+			'q': key(ModNone, 0x04), 'Q': key(ModLeftShift, 0x04), // A position
+			'r': key(ModNone, 0x15), 'R': key(ModLeftShift, 0x15),
+			's': key(ModNone, 0x16), 'S': key(ModLeftShift, 0x16),
+			't': key(ModNone, 0x17), 'T': key(ModLeftShift, 0x17),
+			'u': key(ModNone, 0x18), 'U': key(ModLeftShift, 0x18),
+			'v': key(ModNone, 0x19), 'V': key(ModLeftShift, 0x19),
+			'w': key(ModNone, 0x1D), 'W': key(ModLeftShift, 0x1D), // Z position
+			'x': key(ModNone, 0x1B), 'X': key(ModLeftShift, 0x1B),
+			'y': key(ModNone, 0x1C), 'Y': key(ModLeftShift, 0x1C),
+			'z': key(ModNone, 0x1A), 'Z': key(ModLeftShift, 0x1A), // W position
+		},
+		map[rune]keyStroke{
+			// The number row requires Shift on AZERTY; the unshifted
+			// row produces accented characters instead.
+			'1': key(ModLeftShift, 0x1E), '&': key(ModNone, 0x1E),
+			'2': key(ModLeftShift, 0x1F), 'é': key(ModNone, 0x1F),
+			'3': key(ModLeftShift, 0x20), '"': key(ModNone, 0x20),
+			'4': key(ModLeftShift, 0x21), '\'': key(ModNone, 0x21),
+			'5': key(ModLeftShift, 0x22), '(': key(ModNone, 0x22),
+			'6': key(ModLeftShift, 0x23), '-': key(ModNone, 0x23),
+			'7': key(ModLeftShift, 0x24), 'è': key(ModNone, 0x24),
+			'8': key(ModLeftShift, 0x25), '_': key(ModNone, 0x25),
+			'9': key(ModLeftShift, 0x26), 'ç': key(ModNone, 0x26),
+			'0': key(ModLeftShift, 0x27), 'à': key(ModNone, 0x27),
+			'\n': key(ModNone, 0x28),
+			' ':  key(ModNone, 0x2C),
+			')': key(ModNone, 0x2D), '°': key(ModLeftShift, 0x2D),
+			',': key(ModNone, 0x10), '?': key(ModLeftShift, 0x10), // M position
+			';': key(ModNone, 0x36), '.': key(ModLeftShift, 0x36),
+			':': key(ModNone, 0x37), '/': key(ModLeftShift, 0x37),
+			'!': key(ModNone, 0x38), '§': key(ModLeftShift, 0x38),
+		},
+	),
+	deadKeys: map[rune][2]keyStroke{
+		// The AZERTY ^ key (next to P) is a dead key that combines
+		// with a base vowel to produce a circumflex accent.
+		'ê': {deadKey(ModNone, 0x2F), key(ModNone, 0x08)},
+		'â': {deadKey(ModNone, 0x2F), key(ModNone, 0x14)},
+		'î': {deadKey(ModNone, 0x2F), key(ModNone, 0x0C)},
+		'ô': {deadKey(ModNone, 0x2F), key(ModNone, 0x12)},
+		'û': {deadKey(ModNone, 0x2F), key(ModNone, 0x18)},
+	},
+}
+
+// mergeRuneMaps combines any number of rune->keyStroke maps into one,
+// with later maps overriding earlier ones on key collisions.
+func mergeRuneMaps(maps ...map[rune]keyStroke) map[rune]keyStroke {
+	out := make(map[rune]keyStroke)
+	for _, m := range maps {
+		for r, ks := range m {
+			out[r] = ks
+		}
+	}
+	return out
+}
+
+// cloneRuneMap returns a shallow copy of a rune->keyStroke map.
+func cloneRuneMap(m map[rune]keyStroke) map[rune]keyStroke {
+	return mergeRuneMaps(m)
+}
@@ -8,16 +8,122 @@ const (
 	ButtonMiddle = 0x04
 )
 
+// Extended mouse button bits, carried in the same button byte of the
+// extended report as ButtonLeft/Right/Middle (buttons 4 and 5 of 5).
+const (
+	ButtonBack    = 0x08
+	ButtonForward = 0x10
+)
+
+// Mouse report IDs. The relative report (0x04) is the legacy boot-mouse
+// style report; the absolute report (0x05) carries 16-bit normalized
+// coordinates for digitizer-style pointing; the extended report (0x06)
+// adds a 4th/5th button and a horizontal wheel axis.
+const (
+	MouseReportIDRelative = 0x04
+	MouseReportIDAbsolute = 0x05
+	MouseReportIDExtended = 0x06
+)
+
+// MouseReportDescriptorExtended is the HID report descriptor fragment
+// for MouseReportIDExtended: 5 buttons (3 padding bits in byte 1, a fully
+// reserved byte 2 for future buttons), relative X/Y/wheel, and a
+// Consumer "AC Pan" usage for the horizontal wheel.
+var MouseReportDescriptorExtended = []byte{
+	0x05, 0x01, //       USAGE_PAGE (Generic Desktop)
+	0x09, 0x02, //       USAGE (Mouse)
+	0xA1, 0x01, //       COLLECTION (Application)
+	0x09, 0x01, //         USAGE (Pointer)
+	0xA1, 0x00, //         COLLECTION (Physical)
+	0x85, MouseReportIDExtended, //   REPORT_ID (6)
+	0x05, 0x09, //           USAGE_PAGE (Button)
+	0x19, 0x01, //           USAGE_MINIMUM (Button 1)
+	0x29, 0x05, //           USAGE_MAXIMUM (Button 5)
+	0x15, 0x00, //           LOGICAL_MINIMUM (0)
+	0x25, 0x01, //           LOGICAL_MAXIMUM (1)
+	0x95, 0x05, //           REPORT_COUNT (5)
+	0x75, 0x01, //           REPORT_SIZE (1)
+	0x81, 0x02, //           INPUT (Data,Var,Abs)
+	0x95, 0x03, //           REPORT_COUNT (3)
+	0x75, 0x01, //           REPORT_SIZE (1)
+	0x81, 0x03, //           INPUT (Cnst,Var,Abs) -- pad byte 1 to 8 bits
+	0x95, 0x08, //           REPORT_COUNT (8)
+	0x75, 0x01, //           REPORT_SIZE (1)
+	0x81, 0x03, //           INPUT (Cnst,Var,Abs) -- reserved button byte 2
+	0x05, 0x01, //           USAGE_PAGE (Generic Desktop)
+	0x09, 0x30, //           USAGE (X)
+	0x09, 0x31, //           USAGE (Y)
+	0x09, 0x38, //           USAGE (Wheel)
+	0x15, 0x81, //           LOGICAL_MINIMUM (-127)
+	0x25, 0x7F, //           LOGICAL_MAXIMUM (127)
+	0x75, 0x08, //           REPORT_SIZE (8)
+	0x95, 0x03, //           REPORT_COUNT (3)
+	0x81, 0x06, //           INPUT (Data,Var,Rel)
+	0x05, 0x0C, //           USAGE_PAGE (Consumer)
+	0x0A, 0x38, 0x02, //     USAGE (AC Pan) -- horizontal wheel
+	0x15, 0x81, //           LOGICAL_MINIMUM (-127)
+	0x25, 0x7F, //           LOGICAL_MAXIMUM (127)
+	0x75, 0x08, //           REPORT_SIZE (8)
+	0x95, 0x01, //           REPORT_COUNT (1)
+	0x81, 0x06, //           INPUT (Data,Var,Rel)
+	0xC0, //               END_COLLECTION
+	0xC0, //             END_COLLECTION
+}
+
+// Default logical screen size for absolute positioning, matching the
+// range used by Windows/Linux USB absolute digitizers.
+const (
+	DefaultAbsoluteWidth  = 32767
+	DefaultAbsoluteHeight = 32767
+)
+
+// DescriptorMode selects which mouse report descriptor the host gadget
+// should advertise at enumeration.
+type DescriptorMode int
+
+// Descriptor mode constants
+const (
+	DescriptorModeRelative DescriptorMode = iota
+	DescriptorModeAbsolute
+)
+
 // Mouse represents a HID mouse interface
 type Mouse struct {
 	device *Device
+
+	absolute bool
+	width    int
+	height   int
 }
 
-// NewMouse creates a new mouse interface
+// NewMouse creates a new mouse interface using the relative (legacy)
+// report format.
 func NewMouse(device *Device) *Mouse {
 	return &Mouse{device: device}
 }
 
+// NewMouseAbsolute creates a mouse interface that sends absolute
+// positioning reports normalized against the given logical screen size.
+// A width or height of 0 falls back to the default 32767x32767 range.
+func NewMouseAbsolute(device *Device, width, height int) *Mouse {
+	if width <= 0 {
+		width = DefaultAbsoluteWidth
+	}
+	if height <= 0 {
+		height = DefaultAbsoluteHeight
+	}
+	return &Mouse{device: device, absolute: true, width: width, height: height}
+}
+
+// DescriptorMode reports which HID report descriptor this mouse expects
+// the gadget to advertise at enumeration.
+func (m *Mouse) DescriptorMode() DescriptorMode {
+	if m.absolute {
+		return DescriptorModeAbsolute
+	}
+	return DescriptorModeRelative
+}
+
 // clampMovement clamps a movement value to the valid range (-127 to 127)
 func clampMovement(value int) int8 {
 	if value > 127 {
@@ -87,3 +193,94 @@ func (m *Mouse) Scroll(amount int) error {
 func (m *Mouse) MoveWithButton(button byte, x, y int) error {
 	return m.SendMouseReport(button, x, y, 0)
 }
+
+// clampAbsolute clamps a coordinate to the valid 16-bit unsigned range
+// [0, max] for the absolute positioning report.
+func clampAbsolute(value, max int) uint16 {
+	if value < 0 {
+		return 0
+	}
+	if value > max {
+		return uint16(max)
+	}
+	return uint16(value)
+}
+
+// SendMouseReportAbsolute sends an absolute positioning mouse HID report
+// Report format (6 bytes):
+//
+//	Byte 0:   Report ID (0x05)
+//	Byte 1:   Buttons (bit 0: left, bit 1: right, bit 2: middle, bits 3-7: padding)
+//	Bytes 2-3: X position (unsigned 16-bit little-endian, 0 to width)
+//	Bytes 4-5: Y position (unsigned 16-bit little-endian, 0 to height)
+func (m *Mouse) SendMouseReportAbsolute(buttons byte, x, y int) error {
+	xClamped := clampAbsolute(x, m.width)
+	yClamped := clampAbsolute(y, m.height)
+
+	report := []byte{
+		MouseReportIDAbsolute,
+		buttons,
+		byte(xClamped),      // X low byte
+		byte(xClamped >> 8), // X high byte
+		byte(yClamped),      // Y low byte
+		byte(yClamped >> 8), // Y high byte
+	}
+
+	return m.device.SendReport(report, DefaultDelayMS)
+}
+
+// MoveAbsolute moves the mouse cursor to an absolute position
+func (m *Mouse) MoveAbsolute(x, y int) error {
+	return m.SendMouseReportAbsolute(ButtonNone, x, y)
+}
+
+// ClickAbsolute performs a mouse button click at an absolute position
+func (m *Mouse) ClickAbsolute(button byte, x, y int) error {
+	// Press
+	if err := m.SendMouseReportAbsolute(button, x, y); err != nil {
+		return err
+	}
+	// Release
+	return m.SendMouseReportAbsolute(ButtonNone, x, y)
+}
+
+// MouseState describes an extended mouse report. Buttons is additive
+// over ButtonLeft/Right/Middle/Back/Forward; new button bits can be
+// added here without changing SendMouseReportExtended's signature.
+type MouseState struct {
+	Buttons       uint16
+	X, Y          int
+	Wheel, HWheel int
+}
+
+// SendMouseReportExtended sends a 7-byte extended mouse HID report
+// Report format (7 bytes), matching MouseReportDescriptorExtended:
+//
+//	Byte 0: Report ID (0x06)
+//	Byte 1: Buttons (bit 0: left, bit 1: right, bit 2: middle, bit 3: back
+//	        (0x08), bit 4: forward (0x10), bits 5-7: padding)
+//	Byte 2: Reserved (0x00)
+//	Byte 3: X movement (signed 8-bit, -127 to +127)
+//	Byte 4: Y movement (signed 8-bit, -127 to +127)
+//	Byte 5: Wheel movement (signed 8-bit, -127 to +127)
+//	Byte 6: Horizontal wheel movement (signed 8-bit, -127 to +127)
+func (m *Mouse) SendMouseReportExtended(state MouseState) error {
+	xClamped := clampMovement(state.X)
+	yClamped := clampMovement(state.Y)
+	wheelClamped := clampMovement(state.Wheel)
+	hWheelClamped := clampMovement(state.HWheel)
+
+	buttons := byte(state.Buttons) & (ButtonLeft | ButtonRight | ButtonMiddle | ButtonBack | ButtonForward)
+
+	report := []byte{
+		MouseReportIDExtended,
+		buttons,
+		0x00, // Reserved
+		byte(xClamped),
+		byte(yClamped),
+		byte(wheelClamped),
+		byte(hWheelClamped),
+	}
+
+	return m.device.SendReport(report, DefaultDelayMS)
+}
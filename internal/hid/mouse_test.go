@@ -1,6 +1,7 @@
 package hid
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -171,6 +172,216 @@ func TestMouseReportFormat(t *testing.T) {
 	}
 }
 
+func TestClampAbsolute(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    int
+		max      int
+		expected uint16
+	}{
+		{"zero", 0, 32767, 0},
+		{"mid range", 16000, 32767, 16000},
+		{"max value", 32767, 32767, 32767},
+		{"negative clamps to zero", -100, 32767, 0},
+		{"over max clamps to max", 40000, 32767, 32767},
+		{"custom max", 5000, 1920, 1920},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clampAbsolute(tt.input, tt.max)
+			if got != tt.expected {
+				t.Errorf("clampAbsolute(%d, %d) = %d, want %d", tt.input, tt.max, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMouseReportAbsoluteFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		buttons  byte
+		x        int
+		y        int
+		width    int
+		height   int
+		expected []byte
+	}{
+		{
+			name:     "origin",
+			buttons:  ButtonNone,
+			x:        0,
+			y:        0,
+			width:    32767,
+			height:   32767,
+			expected: []byte{0x05, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "max position",
+			buttons:  ButtonNone,
+			x:        32767,
+			y:        32767,
+			width:    32767,
+			height:   32767,
+			expected: []byte{0x05, 0x00, 0xFF, 0x7F, 0xFF, 0x7F},
+		},
+		{
+			name:     "left button held at mid position",
+			buttons:  ButtonLeft,
+			x:        16000,
+			y:        8000,
+			width:    32767,
+			height:   32767,
+			expected: []byte{0x05, 0x01, 0x80, 0x3E, 0x40, 0x1F},
+		},
+		{
+			name:     "clamped over max",
+			buttons:  ButtonNone,
+			x:        50000,
+			y:        50000,
+			width:    32767,
+			height:   32767,
+			expected: []byte{0x05, 0x00, 0xFF, 0x7F, 0xFF, 0x7F},
+		},
+		{
+			name:     "clamped below zero",
+			buttons:  ButtonNone,
+			x:        -100,
+			y:        -100,
+			width:    32767,
+			height:   32767,
+			expected: []byte{0x05, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xClamped := clampAbsolute(tt.x, tt.width)
+			yClamped := clampAbsolute(tt.y, tt.height)
+
+			report := []byte{
+				MouseReportIDAbsolute,
+				tt.buttons,
+				byte(xClamped),
+				byte(xClamped >> 8),
+				byte(yClamped),
+				byte(yClamped >> 8),
+			}
+
+			if !bytes.Equal(report, tt.expected) {
+				t.Errorf("Report = %v, want %v", report, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMouseDescriptorMode(t *testing.T) {
+	relative := NewMouse(NewDevice(""))
+	if relative.DescriptorMode() != DescriptorModeRelative {
+		t.Errorf("NewMouse DescriptorMode() = %v, want DescriptorModeRelative", relative.DescriptorMode())
+	}
+
+	absolute := NewMouseAbsolute(NewDevice(""), 1920, 1080)
+	if absolute.DescriptorMode() != DescriptorModeAbsolute {
+		t.Errorf("NewMouseAbsolute DescriptorMode() = %v, want DescriptorModeAbsolute", absolute.DescriptorMode())
+	}
+}
+
+func TestNewMouseAbsoluteDefaults(t *testing.T) {
+	m := NewMouseAbsolute(NewDevice(""), 0, 0)
+	if m.width != DefaultAbsoluteWidth || m.height != DefaultAbsoluteHeight {
+		t.Errorf("NewMouseAbsolute(0, 0) = (%d, %d), want (%d, %d)", m.width, m.height, DefaultAbsoluteWidth, DefaultAbsoluteHeight)
+	}
+}
+
+func TestMouseReportExtendedFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    MouseState
+		expected []byte
+	}{
+		{
+			name:     "no movement no buttons",
+			state:    MouseState{},
+			expected: []byte{0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "legacy buttons in byte 1",
+			state:    MouseState{Buttons: ButtonLeft | ButtonMiddle},
+			expected: []byte{0x06, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "back and forward in byte 1",
+			state:    MouseState{Buttons: ButtonBack | ButtonForward},
+			expected: []byte{0x06, 0x18, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "all five buttons held",
+			state:    MouseState{Buttons: ButtonLeft | ButtonRight | ButtonMiddle | ButtonBack | ButtonForward},
+			expected: []byte{0x06, 0x1F, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:     "movement and horizontal wheel",
+			state:    MouseState{X: 10, Y: -10, Wheel: 5, HWheel: -5},
+			expected: []byte{0x06, 0x00, 0x00, 0x0A, 0xF6, 0x05, 0xFB},
+		},
+		{
+			name:     "horizontal wheel clamps over max",
+			state:    MouseState{HWheel: 200},
+			expected: []byte{0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x7F},
+		},
+		{
+			name:     "horizontal wheel clamps under min",
+			state:    MouseState{HWheel: -200},
+			expected: []byte{0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x81},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xClamped := clampMovement(tt.state.X)
+			yClamped := clampMovement(tt.state.Y)
+			wheelClamped := clampMovement(tt.state.Wheel)
+			hWheelClamped := clampMovement(tt.state.HWheel)
+
+			buttons := byte(tt.state.Buttons) & (ButtonLeft | ButtonRight | ButtonMiddle | ButtonBack | ButtonForward)
+
+			report := []byte{
+				MouseReportIDExtended,
+				buttons,
+				0x00, // Reserved
+				byte(xClamped),
+				byte(yClamped),
+				byte(wheelClamped),
+				byte(hWheelClamped),
+			}
+
+			if !bytes.Equal(report, tt.expected) {
+				t.Errorf("Report = %v, want %v", report, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtendedButtonConstants(t *testing.T) {
+	tests := []struct {
+		name     string
+		button   uint16
+		expected uint16
+	}{
+		{"ButtonBack", ButtonBack, 0x08},
+		{"ButtonForward", ButtonForward, 0x10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.button != tt.expected {
+				t.Errorf("%s = 0x%02X, want 0x%02X", tt.name, tt.button, tt.expected)
+			}
+		})
+	}
+}
+
 func TestButtonConstants(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,245 @@
+// Package broadcast records or re-broadcasts the live video feed to a
+// file or a streaming target (RTMP, SRT) via an ffmpeg pipeline that
+// tees the V4L2 device.
+package broadcast
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status reports the current state of a broadcast
+type Status struct {
+	Running      bool      `json:"running"`
+	Target       string    `json:"target"`
+	StartedAt    time.Time `json:"startedAt"`
+	BytesWritten int64     `json:"bytesWritten"`
+	BitrateBps   int64     `json:"bitrateBps"`
+}
+
+// Manager starts and supervises an ffmpeg pipeline that captures a V4L2
+// device and records/re-broadcasts it to a file or streaming target.
+type Manager struct {
+	mu        sync.Mutex
+	running   bool
+	target    string
+	startedAt time.Time
+	cancel    context.CancelFunc
+
+	bytesWritten atomic.Int64
+	bitrateBps   atomic.Int64
+}
+
+// NewManager creates a new broadcast manager
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Start begins recording/broadcasting device to target, which must be a
+// file://, rtmp://, or srt:// URL. RTMP/SRT targets are retried with
+// exponential backoff if the connection drops; recordings to file are
+// not retried since there is nothing transient to recover from.
+func (m *Manager) Start(ctx context.Context, device, target string) error {
+	outputFormat, outputTarget, err := parseTarget(target)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return fmt.Errorf("broadcast already running")
+	}
+	m.running = true
+	m.target = target
+	m.startedAt = time.Now()
+	m.bytesWritten.Store(0)
+	m.bitrateBps.Store(0)
+	pipeCtx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	m.mu.Unlock()
+
+	retryable := outputFormat == "flv" || outputFormat == "mpegts" // rtmp/srt
+	go m.run(pipeCtx, device, outputFormat, outputTarget, retryable)
+
+	log.Printf("Broadcast started: %s -> %s", device, target)
+	return nil
+}
+
+// Stop cancels the ffmpeg pipeline and marks the broadcast as stopped
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return
+	}
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.running = false
+	log.Println("Broadcast stopped")
+}
+
+// IsRunning returns whether a broadcast is currently active
+func (m *Manager) IsRunning() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running
+}
+
+// Status returns a snapshot of the broadcast's current state
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return Status{
+		Running:      m.running,
+		Target:       m.target,
+		StartedAt:    m.startedAt,
+		BytesWritten: m.bytesWritten.Load(),
+		BitrateBps:   m.bitrateBps.Load(),
+	}
+}
+
+// run drives one (or, for retryable targets, several) ffmpeg pipeline
+// attempts, reconnecting with exponential backoff on transient failures
+// such as an RTMP server dropping the connection.
+func (m *Manager) run(ctx context.Context, device, outputFormat, outputTarget string, retryable bool) {
+	backoff := time.Second
+	const maxBackoff = 30 * time.Second
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := m.runOnce(ctx, device, outputFormat, outputTarget); err != nil {
+			log.Printf("Broadcast pipeline error: %v", err)
+		}
+
+		if ctx.Err() != nil || !retryable {
+			m.Stop()
+			return
+		}
+
+		log.Printf("Broadcast to %s disconnected, retrying in %s", outputTarget, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// runOnce starts a single ffmpeg process capturing device and waits for
+// it to finish, tracking progress reported on its stdout along the way.
+func (m *Manager) runOnce(ctx context.Context, device, outputFormat, outputTarget string) error {
+	args := []string{
+		"-f", "v4l2",
+		"-i", device,
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-progress", "pipe:1",
+		"-f", outputFormat,
+		outputTarget,
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create ffmpeg stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create ffmpeg stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("[ffmpeg] %s", scanner.Text())
+		}
+	}()
+
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		m.trackProgress(stdout)
+	}()
+
+	err = cmd.Wait()
+	<-progressDone
+
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("ffmpeg exited: %w", err)
+	}
+	return nil
+}
+
+// trackProgress parses ffmpeg's "-progress pipe:1" key=value output,
+// updating bytesWritten/bitrateBps as new totals are reported.
+func (m *Manager) trackProgress(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "total_size":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				m.bytesWritten.Store(n)
+			}
+		case "bitrate":
+			// ffmpeg reports this as e.g. "1234.5kbits/s" or "N/A"
+			kbits := strings.TrimSuffix(value, "kbits/s")
+			if f, err := strconv.ParseFloat(kbits, 64); err == nil {
+				m.bitrateBps.Store(int64(f * 1000))
+			}
+		}
+	}
+}
+
+func parseTarget(target string) (format, output string, err error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid broadcast target: %w", err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		path := u.Path
+		if path == "" {
+			path = strings.TrimPrefix(target, "file://")
+		}
+		return "mp4", path, nil
+	case "rtmp", "rtmps":
+		return "flv", target, nil
+	case "srt":
+		return "mpegts", target, nil
+	default:
+		return "", "", fmt.Errorf("unsupported broadcast target scheme %q (want file://, rtmp://, or srt://)", u.Scheme)
+	}
+}
@@ -0,0 +1,84 @@
+package video
+
+import (
+	"bytes"
+	"io"
+	"log"
+)
+
+// scanMJPEGFrames reads a stream of concatenated JPEG images from r,
+// delimited by the standard SOI/EOI markers, and sends each complete
+// frame to frames. It returns once r is exhausted or returns an error.
+// Shared by both V4L2Source and RTSPSource, which each produce an MJPEG
+// byte stream from a different underlying pipeline.
+func scanMJPEGFrames(r io.Reader, frames chan<- []byte) {
+	frameStart := []byte{0xFF, 0xD8} // JPEG start marker (SOI)
+	frameEnd := []byte{0xFF, 0xD9}   // JPEG end marker (EOI)
+
+	buf := make([]byte, 4096)
+	currentFrame := make([]byte, 0, 64*1024) // Pre-allocate 64KB to avoid reallocations
+	maxFrameSize := 1024 * 1024              // 1MB max per frame
+
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Error reading frames: %v", err)
+			}
+			return
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		data := buf[:n]
+
+		for len(data) > 0 {
+			if len(currentFrame) == 0 {
+				// Looking for frame start
+				startIdx := bytes.Index(data, frameStart)
+				if startIdx == -1 {
+					// No start marker found, skip this data
+					break
+				}
+				// Found start marker. Only the marker itself is
+				// consumed here - the data after it is still
+				// unprocessed and must go through the endIdx search
+				// below on the next loop iteration, not be appended
+				// again here too.
+				currentFrame = append(currentFrame, frameStart...)
+				data = data[startIdx+len(frameStart):]
+			} else {
+				// Looking for frame end
+				endIdx := bytes.Index(data, frameEnd)
+				if endIdx == -1 {
+					// No end marker yet, append all data
+					currentFrame = append(currentFrame, data...)
+					if len(currentFrame) > maxFrameSize {
+						// Frame too large, discard and start over
+						log.Printf("Frame too large (%d bytes), discarding", len(currentFrame))
+						currentFrame = currentFrame[:0]
+					}
+					break
+				}
+
+				// Found end marker, complete the frame
+				currentFrame = append(currentFrame, data[:endIdx+len(frameEnd)]...)
+
+				// Send frame to channel
+				frame := make([]byte, len(currentFrame))
+				copy(frame, currentFrame)
+				select {
+				case frames <- frame:
+				default:
+					// Drop frame if channel is full (backpressure)
+				}
+
+				// Reset for next frame
+				currentFrame = currentFrame[:0]
+				data = data[endIdx+len(frameEnd):]
+			}
+		}
+	}
+}
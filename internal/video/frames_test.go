@@ -0,0 +1,35 @@
+package video
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestScanMJPEGFramesAcrossReads verifies that a frame larger than the
+// scanner's internal read buffer - the normal case for a real camera
+// frame, which is always many times bigger than the 4096-byte chunks it
+// arrives in - comes out intact and isn't duplicated by the start-marker
+// bytes being double-counted across read boundaries.
+func TestScanMJPEGFramesAcrossReads(t *testing.T) {
+	frame := make([]byte, 9000)
+	frame[0], frame[1] = 0xFF, 0xD8
+	for i := 2; i < len(frame)-2; i++ {
+		frame[i] = 'x'
+	}
+	frame[len(frame)-2], frame[len(frame)-1] = 0xFF, 0xD9
+
+	frames := make(chan []byte, 1)
+	scanMJPEGFrames(bytes.NewReader(frame), frames)
+	close(frames)
+
+	got, ok := <-frames
+	if !ok {
+		t.Fatal("expected one frame, got none")
+	}
+	if !bytes.Equal(got, frame) {
+		t.Fatalf("frame corrupted: got %d bytes, want %d bytes matching the input", len(got), len(frame))
+	}
+	if _, ok := <-frames; ok {
+		t.Fatal("expected exactly one frame")
+	}
+}
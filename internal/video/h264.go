@@ -4,10 +4,12 @@ package video
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os/exec"
 	"regexp"
 	"strconv"
@@ -17,13 +19,21 @@ import (
 	"github.com/coder/websocket"
 )
 
-// H264Streamer manages H264 video streaming via WebSocket
+// H264Streamer captures H264 video from a single camera pipeline and
+// fans it out to any number of registered Sinks (WebSocket viewers, an
+// RTMP restream, ...). The pipeline runs for as long as at least one
+// sink is attached and is torn down once the last one leaves.
 type H264Streamer struct {
 	config H264Config
 
-	// Client management
-	client   *h264Client
-	clientMu sync.Mutex
+	// Sink management
+	sinks   map[Sink]struct{}
+	sinksMu sync.RWMutex
+
+	// RTMP broadcast sink, if any, managed via HandleBroadcastStart/Stop
+	rtmpSink   Sink
+	rtmpTarget string
+	rtmpMu     sync.Mutex
 
 	// Pipeline processes
 	cameraCmd *exec.Cmd
@@ -37,12 +47,6 @@ type H264Streamer struct {
 	cameraModes []CameraMode
 }
 
-// h264Client represents a connected WebSocket client
-type h264Client struct {
-	conn *websocket.Conn
-	done chan struct{}
-}
-
 // H264Config holds configuration for H264 streaming
 type H264Config struct {
 	Width     int
@@ -63,6 +67,7 @@ func NewH264Streamer(config H264Config) *H264Streamer {
 	return &H264Streamer{
 		config:      config,
 		cameraModes: modes,
+		sinks:       make(map[Sink]struct{}),
 	}
 }
 
@@ -93,7 +98,7 @@ func (s *H264Streamer) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stops the video stream
+// Stop stops the video stream and disconnects all sinks
 func (s *H264Streamer) Stop() {
 	s.runningMu.Lock()
 	defer s.runningMu.Unlock()
@@ -107,13 +112,13 @@ func (s *H264Streamer) Stop() {
 		s.cancel()
 	}
 
-	// Disconnect client
-	s.clientMu.Lock()
-	if s.client != nil {
-		close(s.client.done)
-		s.client = nil
+	// Disconnect all sinks
+	s.sinksMu.Lock()
+	for sink := range s.sinks {
+		sink.Close()
 	}
-	s.clientMu.Unlock()
+	s.sinks = make(map[Sink]struct{})
+	s.sinksMu.Unlock()
 
 	s.running = false
 	log.Println("H264 streamer stopped")
@@ -126,6 +131,43 @@ func (s *H264Streamer) IsRunning() bool {
 	return s.running
 }
 
+// addSink registers sink with the fan-out, starting the camera pipeline
+// first if it isn't already running. If starting the pipeline fails,
+// sink is not registered.
+func (s *H264Streamer) addSink(sink Sink) error {
+	s.sinksMu.Lock()
+	first := len(s.sinks) == 0
+	s.sinks[sink] = struct{}{}
+	s.sinksMu.Unlock()
+
+	if !first {
+		return nil
+	}
+
+	if err := s.Start(context.Background()); err != nil {
+		s.sinksMu.Lock()
+		delete(s.sinks, sink)
+		s.sinksMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// removeSink closes sink and unregisters it, stopping the camera
+// pipeline if it was the last one attached.
+func (s *H264Streamer) removeSink(sink Sink) {
+	sink.Close()
+
+	s.sinksMu.Lock()
+	delete(s.sinks, sink)
+	last := len(s.sinks) == 0
+	s.sinksMu.Unlock()
+
+	if last {
+		s.Stop()
+	}
+}
+
 // startPipeline starts the rpicam-vid pipeline
 func (s *H264Streamer) startPipeline(ctx context.Context) error {
 	pipeCtx, cancel := context.WithCancel(ctx)
@@ -212,32 +254,32 @@ func (s *H264Streamer) streamRawH264(r io.Reader) {
 			continue
 		}
 
-		// Send raw H.264 data to client
-		s.sendRawH264(buf[:n])
+		// Fan raw H.264 data out to every registered sink
+		s.broadcastFrame(buf[:n])
 	}
 }
 
-func (s *H264Streamer) sendRawH264(data []byte) {
-	s.clientMu.Lock()
-	client := s.client
-	s.clientMu.Unlock()
-
-	if client == nil {
-		return
-	}
+// broadcastFrame pushes data to every registered sink. Sinks are
+// responsible for their own buffering; this must not block for long on
+// any single slow sink.
+func (s *H264Streamer) broadcastFrame(data []byte) {
+	s.sinksMu.RLock()
+	defer s.sinksMu.RUnlock()
 
-	if err := client.conn.Write(context.Background(), websocket.MessageBinary, data); err != nil {
-		log.Printf("Failed to send H.264 data: %v", err)
+	for sink := range s.sinks {
+		sink.Write(data)
 	}
 }
 
-// HandleWebSocket handles WebSocket connections for H264 streaming
-func (s *H264Streamer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters for dynamic configuration
-	queryParams := r.URL.Query()
-	width := s.config.Width
-	height := s.config.Height
-	framerate := s.config.Framerate
+// reconfigureFromQuery parses the width/height/framerate query
+// parameters shared by HandleWebSocket and WebRTCStreamer.HandleOffer.
+// The first viewer to join picks the resolution/framerate for the
+// camera pipeline; later viewers join the stream as already configured,
+// since one pipeline can't serve two resolutions at once. Returns the
+// resolution/framerate the stream is (or will be) running at, and
+// whether this caller is the one starting it.
+func (s *H264Streamer) reconfigureFromQuery(queryParams url.Values) (width, height, framerate int, first bool) {
+	width, height, framerate = s.config.Width, s.config.Height, s.config.Framerate
 
 	if w := queryParams.Get("width"); w != "" {
 		if parsedWidth, err := parseIntParam(w); err == nil && parsedWidth > 0 {
@@ -255,81 +297,54 @@ func (s *H264Streamer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Check if already streaming to a client
-	s.clientMu.Lock()
-	if s.client != nil {
-		s.clientMu.Unlock()
-		http.Error(w, "Stream already in use", http.StatusConflict)
-		return
+	s.sinksMu.Lock()
+	first = len(s.sinks) == 0
+	if first {
+		s.config.Width = width
+		s.config.Height = height
+		s.config.Framerate = framerate
 	}
+	s.sinksMu.Unlock()
+
+	return width, height, framerate, first
+}
+
+// HandleWebSocket handles WebSocket connections for H264 streaming
+func (s *H264Streamer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	width, height, framerate, first := s.reconfigureFromQuery(r.URL.Query())
 
 	// Accept WebSocket connection
 	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
 		InsecureSkipVerify: true,
 	})
 	if err != nil {
-		s.clientMu.Unlock()
 		log.Printf("Failed to accept WebSocket: %v", err)
 		return
 	}
 
-	// Create new client
-	client := &h264Client{
-		conn: conn,
-		done: make(chan struct{}),
-	}
-	s.client = client
-	s.clientMu.Unlock()
+	sink := newWebSocketSink(conn)
 
-	// Start or reconfigure streamer
-	needsStart := !s.IsRunning()
-	needsRestart := s.IsRunning() &&
-		(width != s.config.Width || height != s.config.Height || framerate != s.config.Framerate)
-
-	if needsRestart {
-		log.Printf("Reconfiguring stream: %dx%d @ %dfps", width, height, framerate)
-		s.Stop()
-		s.config.Width = width
-		s.config.Height = height
-		s.config.Framerate = framerate
-		needsStart = true
+	if err := s.addSink(sink); err != nil {
+		log.Printf("Failed to start stream: %v", err)
+		_ = conn.Close(websocket.StatusInternalError, "Failed to start stream")
+		return
 	}
 
-	if needsStart {
-		if !needsRestart {
-			log.Printf("Starting stream: %dx%d @ %dfps", width, height, framerate)
-			s.config.Width = width
-			s.config.Height = height
-			s.config.Framerate = framerate
-		}
-
-		ctx := r.Context()
-		if err := s.Start(ctx); err != nil {
-			log.Printf("Failed to start stream: %v", err)
-			s.clientMu.Lock()
-			s.client = nil
-			s.clientMu.Unlock()
-			_ = conn.Close(websocket.StatusInternalError, "Failed to start stream")
-			return
-		}
+	if first {
+		log.Printf("Starting stream: %dx%d @ %dfps", width, height, framerate)
+	} else if width != s.config.Width || height != s.config.Height || framerate != s.config.Framerate {
+		log.Printf("Ignoring requested %dx%d @ %dfps: stream already running at %dx%d @ %dfps",
+			width, height, framerate, s.config.Width, s.config.Height, s.config.Framerate)
 	}
 
 	// Cleanup on disconnect
 	defer func() {
-		s.clientMu.Lock()
-		if s.client == client {
-			s.client = nil
-		}
-		s.clientMu.Unlock()
+		s.removeSink(sink)
 		_ = conn.Close(websocket.StatusNormalClosure, "")
-
-		// Stop the stream when client disconnects
-		s.Stop()
-
 		log.Printf("H264 client disconnected: %s", r.RemoteAddr)
 	}()
 
-	log.Printf("H264 client connected: %s (%dx%d @ %dfps)", r.RemoteAddr, width, height, framerate)
+	log.Printf("H264 client connected: %s", r.RemoteAddr)
 
 	// Keep connection alive and wait for disconnect
 	for {
@@ -340,6 +355,81 @@ func (s *H264Streamer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleBroadcastStart starts restreaming the live H.264 feed, unmodified,
+// to the RTMP target given in the "url" query parameter, registering it
+// as a sink alongside any connected WebSocket viewers.
+func (s *H264Streamer) HandleBroadcastStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	target := r.URL.Query().Get("url")
+	if target == "" {
+		http.Error(w, "Missing url query parameter", http.StatusBadRequest)
+		return
+	}
+
+	s.rtmpMu.Lock()
+	if s.rtmpSink != nil {
+		s.rtmpMu.Unlock()
+		http.Error(w, "Broadcast already running", http.StatusConflict)
+		return
+	}
+
+	sink, err := newRTMPBroadcastSink(context.Background(), target)
+	if err != nil {
+		s.rtmpMu.Unlock()
+		log.Printf("Failed to start RTMP broadcast sink: %v", err)
+		http.Error(w, "Failed to start broadcast", http.StatusInternalServerError)
+		return
+	}
+	s.rtmpSink = sink
+	s.rtmpTarget = target
+	s.rtmpMu.Unlock()
+
+	if err := s.addSink(sink); err != nil {
+		s.rtmpMu.Lock()
+		s.rtmpSink = nil
+		s.rtmpTarget = ""
+		s.rtmpMu.Unlock()
+		log.Printf("Failed to start camera pipeline for RTMP broadcast: %v", err)
+		http.Error(w, "Failed to start broadcast", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("RTMP broadcast started: -> %s", target)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "started"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// HandleBroadcastStop stops the active RTMP broadcast sink, if any.
+func (s *H264Streamer) HandleBroadcastStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.rtmpMu.Lock()
+	sink := s.rtmpSink
+	s.rtmpSink = nil
+	s.rtmpTarget = ""
+	s.rtmpMu.Unlock()
+
+	if sink != nil {
+		s.removeSink(sink)
+		log.Println("RTMP broadcast stopped")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "stopped"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
 func parseIntParam(s string) (int, error) {
 	var result int
 	_, err := fmt.Sscanf(s, "%d", &result)
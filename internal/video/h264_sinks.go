@@ -0,0 +1,254 @@
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// Sink receives the raw H.264 byte stream read from the capture pipeline,
+// in whatever chunks each read returned, with no regard for NAL
+// boundaries. The slice passed to Write is only valid for the duration of
+// the call: the reader reuses its buffer for the next read. Write must not block the reader in streamRawH264 for long; a sink that
+// cannot keep up is responsible for either dropping data itself (as
+// websocketSink does) or accepting the resulting backpressure (as
+// execSink does).
+type Sink interface {
+	Write(frame []byte)
+	Close()
+}
+
+// websocketSink fans frames out to a single connected WebSocket viewer
+// over a small bounded queue, dropping the oldest buffered frame rather
+// than blocking when the viewer falls behind.
+type websocketSink struct {
+	conn   *websocket.Conn
+	frames chan []byte
+	done   chan struct{}
+
+	closeMu sync.Mutex
+	closed  bool
+}
+
+// newWebSocketSink creates a websocketSink and starts its pump
+// goroutine, which writes queued frames to conn until the sink is
+// closed or the write fails.
+func newWebSocketSink(conn *websocket.Conn) *websocketSink {
+	s := &websocketSink{
+		conn:   conn,
+		frames: make(chan []byte, 64),
+		done:   make(chan struct{}),
+	}
+	go s.pump()
+	return s
+}
+
+func (s *websocketSink) pump() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case frame, ok := <-s.frames:
+			if !ok {
+				return
+			}
+			if err := s.conn.Write(context.Background(), websocket.MessageBinary, frame); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Write queues frame for delivery, dropping the oldest buffered frame to
+// make room if the viewer is behind.
+func (s *websocketSink) Write(frame []byte) {
+	// frame aliases the reader's buffer, so queue a copy of it.
+	frame = append([]byte(nil), frame...)
+
+	select {
+	case s.frames <- frame:
+	default:
+		select {
+		case <-s.frames:
+		default:
+		}
+		select {
+		case s.frames <- frame:
+		default:
+		}
+	}
+}
+
+func (s *websocketSink) Close() {
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+}
+
+// execSink pipes raw H.264 into an external process's stdin, such as
+// ffmpeg restreaming to RTMP. Unlike websocketSink it does not drop
+// frames: losing NAL units mid-stream corrupts decode until the next
+// keyframe, so a slow downstream command instead applies backpressure
+// to the capture pipeline via a blocking Write.
+type execSink struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// newExecSink starts name with args, piping Write calls to its stdin
+// and logging its stderr.
+func newExecSink(ctx context.Context, name string, args ...string) (*execSink, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("[%s] %s", name, scanner.Text())
+		}
+	}()
+
+	return &execSink{cmd: cmd, stdin: stdin}, nil
+}
+
+func (s *execSink) Write(frame []byte) {
+	if _, err := s.stdin.Write(frame); err != nil {
+		log.Printf("exec sink write error: %v", err)
+	}
+}
+
+func (s *execSink) Close() {
+	_ = s.stdin.Close()
+	_ = s.cmd.Wait()
+}
+
+// newRTMPBroadcastSink starts an execSink that restreams raw H.264 read
+// from stdin to target via ffmpeg, without re-encoding.
+func newRTMPBroadcastSink(ctx context.Context, target string) (*execSink, error) {
+	return newExecSink(ctx, "ffmpeg",
+		"-f", "h264", "-i", "pipe:0",
+		"-c:v", "copy",
+		"-f", "flv",
+		target,
+	)
+}
+
+// h264StartCode is the 3-byte Annex B NAL start code. The 4-byte form
+// (00 00 00 01) is just this one preceded by an extra zero byte, which
+// naturally ends up tacked onto the end of the preceding NAL when
+// scanning for this pattern - a trailing zero byte, which Annex B allows
+// after a NAL, so nothing extra needs to be done to handle it.
+var h264StartCode = []byte{0x00, 0x00, 0x01}
+
+// maxH264NALSize bounds how large webrtcSink lets a buffered, not-yet-
+// complete NAL unit grow before giving up on it, the same way
+// scanMJPEGFrames bounds a buffered JPEG frame: a real access unit is
+// well under this, so growth past it means the stream is corrupt or
+// start codes were missed, not a slow producer.
+const maxH264NALSize = 4 * 1024 * 1024
+
+// webrtcSink hands raw H.264 data read from the capture pipeline to a
+// WebRTC track as media samples, letting pion handle RTP packetization.
+// Like execSink it has no bounded queue that drops data under load,
+// relying on the pipeline's own backpressure instead; it only discards
+// bytes before the first start code and a buffered NAL that grows past
+// maxH264NALSize.
+//
+// streamRawH264 hands Write whatever arbitrary chunk r.Read returned,
+// with no regard for NAL boundaries, so Write buffers across calls and
+// only turns a chunk into a media.Sample once a full NAL unit -
+// delimited by the next Annex B start code - has arrived. Handing
+// WriteSample an arbitrary, possibly mid-NAL slice would corrupt the
+// bitstream every real camera frame produces, since any I-frame NAL
+// easily exceeds the 32KB read buffer.
+type webrtcSink struct {
+	track    *webrtc.TrackLocalStaticSample
+	duration time.Duration
+
+	buf []byte
+}
+
+// newWebRTCSink creates a webrtcSink that paces samples written to
+// track at framerate.
+func newWebRTCSink(track *webrtc.TrackLocalStaticSample, framerate int) *webrtcSink {
+	if framerate <= 0 {
+		framerate = 30
+	}
+	return &webrtcSink{track: track, duration: time.Second / time.Duration(framerate)}
+}
+
+// Write appends frame to the buffered NAL unit in progress and flushes
+// every NAL that becomes complete as its own media.Sample, leaving any
+// trailing, still-incomplete NAL buffered for the next call.
+func (s *webrtcSink) Write(frame []byte) {
+	s.buf = append(s.buf, frame...)
+
+	var nals [][]byte
+	nals, s.buf = extractH264NALs(s.buf)
+
+	if len(s.buf) > maxH264NALSize {
+		log.Printf("webrtc sink: NAL unit exceeded %d bytes without a terminating start code, discarding", maxH264NALSize)
+		s.buf = s.buf[:0]
+	}
+
+	for _, nal := range nals {
+		if err := s.track.WriteSample(media.Sample{Data: nal, Duration: s.duration}); err != nil {
+			log.Printf("webrtc sink write error: %v", err)
+		}
+	}
+}
+
+// extractH264NALs scans buf for complete Annex B NAL units - each
+// running from a start code up to (but not including) the next one -
+// and returns them in order, along with whatever trailing bytes form an
+// as-yet-incomplete NAL for the caller to buffer and prepend to the next
+// call. Any bytes before the first start code are dropped, since an
+// Annex B stream always begins with one.
+func extractH264NALs(buf []byte) (nals [][]byte, rest []byte) {
+	start := bytes.Index(buf, h264StartCode)
+	if start == -1 {
+		return nil, buf
+	}
+	buf = buf[start:]
+
+	for {
+		next := bytes.Index(buf[len(h264StartCode):], h264StartCode)
+		if next == -1 {
+			return nals, buf
+		}
+		next += len(h264StartCode)
+
+		nal := make([]byte, next)
+		copy(nal, buf[:next])
+		nals = append(nals, nal)
+		buf = buf[next:]
+	}
+}
+
+func (s *webrtcSink) Close() {}
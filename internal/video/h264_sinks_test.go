@@ -0,0 +1,58 @@
+package video
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestExtractH264NALsAcrossWrites verifies that a NAL unit split across
+// two Write-sized chunks - the normal case for a real I-frame, which is
+// easily several times bigger than streamRawH264's 32KB read buffer -
+// comes out as one intact sample instead of being torn in half at the
+// chunk boundary.
+func TestExtractH264NALsAcrossWrites(t *testing.T) {
+	nal1 := make([]byte, 0, 40000)
+	nal1 = append(nal1, h264StartCode...)
+	for i := 0; i < 40000-len(h264StartCode); i++ {
+		nal1 = append(nal1, 'a')
+	}
+
+	nal2 := make([]byte, 0, 100)
+	nal2 = append(nal2, h264StartCode...)
+	nal2 = append(nal2, []byte("bbbbbb")...)
+
+	// Split the combined stream at an arbitrary offset inside nal1, the
+	// way a 32KB r.Read would split a much larger real NAL.
+	combined := append(append([]byte{}, nal1...), nal2...)
+	chunk1, chunk2 := combined[:32*1024], combined[32*1024:]
+
+	nals, rest := extractH264NALs(chunk1)
+	if len(nals) != 0 {
+		t.Fatalf("expected no complete NALs from the first chunk alone, got %d", len(nals))
+	}
+
+	nals, rest = extractH264NALs(append(rest, chunk2...))
+	if len(nals) != 1 {
+		t.Fatalf("expected exactly one complete NAL, got %d", len(nals))
+	}
+	if !bytes.Equal(nals[0], nal1) {
+		t.Fatalf("NAL 1 corrupted: got %d bytes, want %d bytes matching the input", len(nals[0]), len(nal1))
+	}
+	if !bytes.Equal(rest, nal2) {
+		t.Fatalf("trailing incomplete NAL mismatch: got %d bytes, want %d bytes matching nal2", len(rest), len(nal2))
+	}
+}
+
+// TestExtractH264NALsDropsLeadingNoise verifies that bytes before the
+// first start code - which can never belong to a complete NAL, since an
+// Annex B stream always opens with one - are discarded rather than
+// retained forever.
+func TestExtractH264NALsDropsLeadingNoise(t *testing.T) {
+	noise := []byte{0x01, 0x02, 0x03}
+	nal := append(append([]byte{}, h264StartCode...), []byte("payload")...)
+
+	_, rest := extractH264NALs(append(append([]byte{}, noise...), nal...))
+	if !bytes.Equal(rest, nal) {
+		t.Fatalf("leading noise not dropped: got %d bytes, want %d bytes matching the NAL alone", len(rest), len(nal))
+	}
+}
@@ -0,0 +1,133 @@
+package video
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// WebRTCConfig holds configuration for negotiating WebRTC sessions
+// against a shared H264Streamer.
+type WebRTCConfig struct {
+	ICEServers []webrtc.ICEServer
+}
+
+// WebRTCStreamer negotiates WebRTC sessions over the same rpicam-vid
+// pipeline and sink fan-out used by H264Streamer.HandleWebSocket, so
+// WebSocket and WebRTC viewers can watch the same camera process
+// concurrently. Unlike the WHEP-based webrtc.Streamer (which feeds
+// pre-packetized RTP from its own ffmpeg pipeline), it packs the raw
+// H.264 read from the shared camera pipeline into RTP via a
+// TrackLocalStaticSample.
+type WebRTCStreamer struct {
+	h264   *H264Streamer
+	config WebRTCConfig
+}
+
+// NewWebRTCStreamer creates a WebRTCStreamer that negotiates sessions
+// against h264's camera pipeline.
+func NewWebRTCStreamer(h264 *H264Streamer, config WebRTCConfig) *WebRTCStreamer {
+	return &WebRTCStreamer{h264: h264, config: config}
+}
+
+// HandleOffer negotiates a new WebRTC session: it accepts an SDP offer
+// in the request body, registers a sink carrying an H.264 sample track
+// on the shared camera pipeline, and responds with the SDP answer. It
+// honors the same width/height/framerate query parameters as
+// HandleWebSocket to configure the pipeline if this is the first viewer
+// to join.
+func (s *WebRTCStreamer) HandleOffer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	offer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	width, height, framerate, first := s.h264.reconfigureFromQuery(r.URL.Query())
+
+	track, err := webrtc.NewTrackLocalStaticSample(
+		webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264},
+		"video", "kindavm",
+	)
+	if err != nil {
+		log.Printf("Failed to create WebRTC track: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{ICEServers: s.config.ICEServers})
+	if err != nil {
+		log.Printf("Failed to create peer connection: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := pc.AddTrack(track); err != nil {
+		log.Printf("Failed to add track to peer connection: %v", err)
+		_ = pc.Close()
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sink := newWebRTCSink(track, framerate)
+
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		switch state {
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed, webrtc.PeerConnectionStateDisconnected:
+			s.h264.removeSink(sink)
+		}
+	})
+
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeOffer,
+		SDP:  string(offer),
+	}); err != nil {
+		log.Printf("Failed to set remote description: %v", err)
+		_ = pc.Close()
+		http.Error(w, "Invalid SDP offer", http.StatusBadRequest)
+		return
+	}
+
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		log.Printf("Failed to create answer: %v", err)
+		_ = pc.Close()
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	gatherComplete := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(answer); err != nil {
+		log.Printf("Failed to set local description: %v", err)
+		_ = pc.Close()
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	<-gatherComplete
+
+	if err := s.h264.addSink(sink); err != nil {
+		log.Printf("Failed to start stream for WebRTC viewer: %v", err)
+		_ = pc.Close()
+		http.Error(w, "Failed to start stream", http.StatusInternalServerError)
+		return
+	}
+
+	if first {
+		log.Printf("Starting stream: %dx%d @ %dfps", width, height, framerate)
+	} else if width != s.h264.config.Width || height != s.h264.config.Height || framerate != s.h264.config.Framerate {
+		log.Printf("Ignoring requested %dx%d @ %dfps: stream already running at %dx%d @ %dfps",
+			width, height, framerate, s.h264.config.Width, s.h264.config.Height, s.h264.config.Framerate)
+	}
+
+	w.Header().Set("Content-Type", "application/sdp")
+	if _, err := w.Write([]byte(pc.LocalDescription().SDP)); err != nil {
+		log.Printf("Failed to write SDP answer: %v", err)
+	}
+}
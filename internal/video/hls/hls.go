@@ -0,0 +1,337 @@
+// Package hls provides HLS (HTTP Live Streaming) output for the video
+// feed: a rolling window of short MPEG-TS segments behind a live m3u8
+// playlist, for clients and proxies that don't tolerate long-lived
+// WebSocket or multipart-MJPEG connections.
+package hls
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config holds configuration for the HLS segmenter.
+type Config struct {
+	Device          string        // V4L2 device to capture from
+	SegmentDuration time.Duration // target length of each TS segment
+	WindowSize      int           // number of segments kept in the live playlist
+}
+
+// DefaultConfig returns sensible defaults: 2s segments, a 3-segment
+// sliding window.
+func DefaultConfig(device string) Config {
+	return Config{
+		Device:          device,
+		SegmentDuration: 2 * time.Second,
+		WindowSize:      3,
+	}
+}
+
+// Segment is one rolling TS segment of the live playlist. ready is
+// closed once data has been populated, so a request for a segment
+// that's still being written can block on it instead of 404ing.
+type Segment struct {
+	seq   int
+	data  []byte
+	ready chan struct{}
+}
+
+// segmentFilenameRe matches the "seg_N.ts" names ffmpeg's hls muxer
+// writes and that the playlist/segment handlers parse sequence numbers
+// from.
+var segmentFilenameRe = regexp.MustCompile(`seg_(\d+)\.ts`)
+
+// Manager runs an ffmpeg pipeline that segments the video feed into
+// rolling MPEG-TS files, watches ffmpeg's own playlist to learn when
+// each segment is complete, and serves a sliding-window m3u8 playlist
+// and the segments themselves over HTTP.
+type Manager struct {
+	config Config
+
+	mu       sync.Mutex
+	segments map[int]*Segment
+	nextSeq  int
+	dir      string
+	running  bool
+
+	cmd       *exec.Cmd
+	cmdCancel context.CancelFunc
+}
+
+// NewManager creates a new HLS segmenter manager.
+func NewManager(config Config) *Manager {
+	return &Manager{config: config, segments: make(map[int]*Segment)}
+}
+
+// Start launches the ffmpeg segmenting pipeline and begins watching for
+// completed segments.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return fmt.Errorf("HLS segmenter already running")
+	}
+
+	dir, err := os.MkdirTemp("", "kindavm-hls-*")
+	if err != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("failed to create segment directory: %w", err)
+	}
+
+	cmdCtx, cancel := context.WithCancel(ctx)
+	m.dir = dir
+	m.segments = make(map[int]*Segment)
+	m.nextSeq = 0
+	m.running = true
+	m.cmdCancel = cancel
+	m.mu.Unlock()
+
+	args := []string{
+		"-f", "v4l2",
+		"-i", m.config.Device,
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-f", "hls",
+		"-hls_time", fmt.Sprintf("%g", m.config.SegmentDuration.Seconds()),
+		"-hls_list_size", fmt.Sprintf("%d", m.config.WindowSize),
+		"-hls_flags", "delete_segments+append_list",
+		"-hls_segment_filename", filepath.Join(dir, "seg_%d.ts"),
+		filepath.Join(dir, "stream.m3u8"),
+	}
+
+	cmd := exec.CommandContext(cmdCtx, "ffmpeg", args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to create ffmpeg stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	m.cmd = cmd
+
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("[ffmpeg-hls] %s", scanner.Text())
+		}
+	}()
+
+	go m.watchSegments(cmdCtx)
+
+	go func() {
+		err := cmd.Wait()
+		if err != nil && cmdCtx.Err() == nil {
+			log.Printf("ffmpeg (HLS) exited with error: %v", err)
+		}
+	}()
+
+	log.Println("HLS segmenter started")
+	return nil
+}
+
+// Stop terminates the ffmpeg pipeline and removes the segment directory.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return
+	}
+	m.running = false
+	dir := m.dir
+	m.mu.Unlock()
+
+	if m.cmdCancel != nil {
+		m.cmdCancel()
+	}
+	if dir != "" {
+		_ = os.RemoveAll(dir)
+	}
+	log.Println("HLS segmenter stopped")
+}
+
+// IsRunning returns whether the HLS segmenter is currently running.
+func (m *Manager) IsRunning() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running
+}
+
+// watchSegments polls ffmpeg's own playlist for newly completed
+// segments. ffmpeg only lists a segment once it has finished writing
+// and closed it, so a name appearing in the playlist is a reliable
+// completion signal without us having to parse TS ourselves.
+func (m *Manager) watchSegments(ctx context.Context) {
+	interval := m.config.SegmentDuration / 4
+	if interval <= 0 {
+		interval = 250 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen := make(map[int]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.scanPlaylist(seen)
+		}
+	}
+}
+
+// scanPlaylist reads ffmpeg's playlist file and registers any segment
+// listed in it that hasn't been loaded yet.
+func (m *Manager) scanPlaylist(seen map[int]bool) {
+	data, err := os.ReadFile(filepath.Join(m.dir, "stream.m3u8"))
+	if err != nil {
+		return // playlist not written yet
+	}
+
+	for _, name := range segmentFilenameRe.FindAllString(string(data), -1) {
+		seq, err := segmentSeq(name)
+		if err != nil || seen[seq] {
+			continue
+		}
+
+		segData, err := os.ReadFile(filepath.Join(m.dir, name))
+		if err != nil {
+			continue // ffmpeg may still be finalizing; retry next tick
+		}
+
+		seen[seq] = true
+		m.completeSegment(seq, segData)
+	}
+}
+
+// completeSegment stores a finished segment's data and pre-registers a
+// placeholder for the next one, so a request that arrives while ffmpeg
+// is still writing it finds a ready channel to block on rather than a
+// 404.
+func (m *Manager) completeSegment(seq int, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seg, ok := m.segments[seq]
+	if !ok {
+		seg = &Segment{seq: seq, ready: make(chan struct{})}
+		m.segments[seq] = seg
+	}
+	seg.data = data
+	close(seg.ready)
+
+	if seq >= m.nextSeq {
+		m.nextSeq = seq + 1
+	}
+	if _, ok := m.segments[m.nextSeq]; !ok {
+		m.segments[m.nextSeq] = &Segment{seq: m.nextSeq, ready: make(chan struct{})}
+	}
+
+	for s := range m.segments {
+		if s < m.nextSeq-m.config.WindowSize {
+			delete(m.segments, s)
+		}
+	}
+}
+
+// ServePlaylist writes a live m3u8 playlist listing the segments
+// currently in the sliding window.
+func (m *Manager) ServePlaylist(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	running := m.running
+	segs := make([]*Segment, 0, len(m.segments))
+	for _, seg := range m.segments {
+		if seg.data != nil {
+			segs = append(segs, seg)
+		}
+	}
+	m.mu.Unlock()
+
+	if !running {
+		http.Error(w, "HLS stream not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	sort.Slice(segs, func(i, j int) bool { return segs[i].seq < segs[j].seq })
+
+	var b strings.Builder
+	targetDuration := int(m.config.SegmentDuration.Seconds() + 0.999)
+	fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n", targetDuration)
+	if len(segs) > 0 {
+		fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", segs[0].seq)
+	}
+	for _, seg := range segs {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\nseg_%d.ts\n", m.config.SegmentDuration.Seconds(), seg.seq)
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// ServeSegment serves a single TS segment by sequence number, blocking
+// if the segment has been allocated but ffmpeg hasn't finished writing
+// it yet.
+func (m *Manager) ServeSegment(w http.ResponseWriter, r *http.Request) {
+	seq, err := segmentSeq(filepath.Base(r.URL.Path))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		http.Error(w, "HLS stream not running", http.StatusServiceUnavailable)
+		return
+	}
+	seg, ok := m.segments[seq]
+	if !ok {
+		seg = &Segment{seq: seq, ready: make(chan struct{})}
+		m.segments[seq] = seg
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-seg.ready:
+	case <-r.Context().Done():
+		return
+	case <-time.After(4 * m.config.SegmentDuration):
+		http.Error(w, "timed out waiting for segment", http.StatusGatewayTimeout)
+		return
+	}
+
+	m.mu.Lock()
+	data := seg.data
+	m.mu.Unlock()
+
+	if data == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "video/mp2t")
+	_, _ = w.Write(data)
+}
+
+// segmentSeq extracts the sequence number from a "seg_N.ts" filename.
+func segmentSeq(name string) (int, error) {
+	matches := segmentFilenameRe.FindStringSubmatch(name)
+	if matches == nil {
+		return 0, fmt.Errorf("not a segment filename: %q", name)
+	}
+	return strconv.Atoi(matches[1])
+}
@@ -0,0 +1,97 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// connectRetryInterval and connectRetryTimeout bound how long Start waits
+// for the upstream process (e.g. a just-forked ustreamer) to bind its
+// listener, since startUstreamer only starts the process and doesn't wait
+// for it to become ready.
+const (
+	connectRetryInterval = 200 * time.Millisecond
+	connectRetryTimeout  = 5 * time.Second
+)
+
+// HTTPMJPEGSource consumes an MJPEG multipart stream already being served
+// over HTTP by another process - such as the ustreamer binary started for
+// /video/start - instead of opening its own capture device or RTSP
+// session. This lets a second consumer (the /ws binary frame push) ride
+// the one capture already running instead of contending with it for the
+// same V4L2 device or RTSP session.
+type HTTPMJPEGSource struct {
+	url string
+
+	cancel context.CancelFunc
+}
+
+// NewHTTPMJPEGSource creates a new HTTPMJPEGSource that reads frames from
+// the MJPEG multipart stream at url.
+func NewHTTPMJPEGSource(url string) *HTTPMJPEGSource {
+	return &HTTPMJPEGSource{url: url}
+}
+
+// Start connects to the MJPEG stream and scans it for frames the same way
+// V4L2Source and RTSPSource scan their own subprocess output: scanMJPEGFrames
+// only looks for JPEG SOI/EOI markers, so the multipart boundary and part
+// headers in between are skipped over like any other non-frame noise.
+func (h *HTTPMJPEGSource) Start(ctx context.Context, frames chan<- []byte) error {
+	reqCtx, cancel := context.WithCancel(ctx)
+	h.cancel = cancel
+
+	resp, err := h.connect(reqCtx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	go func() {
+		defer resp.Body.Close()
+		scanMJPEGFrames(resp.Body, frames)
+	}()
+
+	return nil
+}
+
+// connect dials h.url, retrying on connection failures for up to
+// connectRetryTimeout - the upstream process (e.g. a just-started
+// ustreamer) may still be binding its listener when the first WS
+// subscriber arrives, since starting that process and it becoming ready
+// to accept connections aren't the same moment.
+func (h *HTTPMJPEGSource) connect(ctx context.Context) (*http.Response, error) {
+	deadline := time.Now().Add(connectRetryTimeout)
+	var lastErr error
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request: %w", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to connect to %s: %w", h.url, lastErr)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to connect to %s: %w", h.url, ctx.Err())
+		case <-time.After(connectRetryInterval):
+		}
+	}
+}
+
+// Stop closes the HTTP connection to the upstream MJPEG stream.
+func (h *HTTPMJPEGSource) Stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+}
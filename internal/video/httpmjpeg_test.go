@@ -0,0 +1,83 @@
+package video
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHTTPMJPEGSourceExtractsFrames verifies that HTTPMJPEGSource pulls
+// individual JPEG frames out of a multipart MJPEG HTTP response, the
+// same way V4L2Source and RTSPSource extract frames from their own
+// subprocess output, so a WS mjpeg-ts subscriber can ride an already-
+// running ustreamer process instead of starting a second capture.
+func TestHTTPMJPEGSourceExtractsFrames(t *testing.T) {
+	// Padded well past scanMJPEGFrames' 4096-byte read buffer, like a
+	// real JPEG frame, so each frame spans several Read calls the way it
+	// would from a real camera instead of landing in a single one.
+	frame1 := makeTestJPEGFrame('1')
+	frame2 := makeTestJPEGFrame('2')
+
+	// The handler keeps the connection open and flushes after each part,
+	// like a real ustreamer stream would, instead of closing once both
+	// frames are written. A closed connection can deliver its last chunk
+	// of data and io.EOF in the same Read, which scanMJPEGFrames doesn't
+	// handle - not something a long-lived MJPEG stream ever does, so it's
+	// not this fix's bug to chase, but worth keeping the fixture realistic.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary=frame")
+		flusher := w.(http.Flusher)
+		_, _ = w.Write([]byte("--frame\r\nContent-Type: image/jpeg\r\n\r\n"))
+		_, _ = w.Write(frame1)
+		flusher.Flush()
+		_, _ = w.Write([]byte("\r\n--frame\r\nContent-Type: image/jpeg\r\n\r\n"))
+		_, _ = w.Write(frame2)
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	source := NewHTTPMJPEGSource(srv.URL)
+	frames := make(chan []byte, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := source.Start(ctx, frames); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer source.Stop()
+
+	var got [][]byte
+	for i := 0; i < 2; i++ {
+		select {
+		case f := <-frames:
+			got = append(got, f)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for frame %d", i+1)
+		}
+	}
+
+	if string(got[0]) != string(frame1) {
+		t.Errorf("frame 1 mismatch (len got=%d want=%d)", len(got[0]), len(frame1))
+	}
+	if string(got[1]) != string(frame2) {
+		t.Errorf("frame 2 mismatch (len got=%d want=%d)", len(got[1]), len(frame2))
+	}
+}
+
+// makeTestJPEGFrame builds a fake JPEG frame bracketed by real SOI/EOI
+// markers, padded to 8KB so it spans multiple scanMJPEGFrames reads the
+// way an actual camera frame would. fill distinguishes the two frames'
+// payloads from each other.
+func makeTestJPEGFrame(fill byte) []byte {
+	frame := make([]byte, 8192)
+	frame[0], frame[1] = 0xFF, 0xD8
+	for i := 2; i < len(frame)-2; i++ {
+		frame[i] = fill
+	}
+	frame[len(frame)-2], frame[len(frame)-1] = 0xFF, 0xD9
+	return frame
+}
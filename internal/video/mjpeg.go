@@ -2,31 +2,31 @@
 package video
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
-	"os/exec"
 	"sync"
 	"time"
 )
 
-// MJPEGStreamer manages MJPEG video streaming from a camera
+// MJPEGStreamer manages MJPEG video streaming from a Source, fanning each
+// frame out to every connected viewer
 type MJPEGStreamer struct {
-	config Config
+	source Source
 
-	// Client management
-	client   *mjpegClient
-	clientMu sync.Mutex
+	// Client management. clients is only ever mutated by dispatch; other
+	// goroutines join/leave via register/unregister.
+	clients    map[*mjpegClient]struct{}
+	clientMu   sync.Mutex
+	register   chan *mjpegClient
+	unregister chan *mjpegClient
 
-	// Camera process
-	cmd       *exec.Cmd
-	cmdCancel context.CancelFunc
+	// lastFrame is handed to newly registered clients so they don't have
+	// to wait for the next frame to arrive.
+	lastFrame []byte
 
 	// Frame distribution
 	frameChan chan []byte
@@ -34,26 +34,96 @@ type MJPEGStreamer struct {
 	runningMu sync.Mutex
 }
 
-// mjpegClient represents a connected client
+// mjpegClient represents a connected viewer. frames is a small bounded
+// channel; the dispatcher drops the oldest buffered frame rather than
+// blocking when a viewer falls behind.
 type mjpegClient struct {
-	writer http.ResponseWriter
+	frames chan []byte
 	done   chan struct{}
 }
 
-// Config holds configuration for video streaming
-type Config struct {
-	Width     int
-	Height    int
-	Framerate int
-	Quality   int
+// NewMJPEGStreamer creates a new MJPEG streamer that captures frames from
+// a local V4L2 device via rpicam-vid
+func NewMJPEGStreamer(config Config) *MJPEGStreamer {
+	return NewMJPEGStreamerWithSource(NewV4L2Source(config))
 }
 
-// NewMJPEGStreamer creates a new MJPEG streamer
-func NewMJPEGStreamer(config Config) *MJPEGStreamer {
-	return &MJPEGStreamer{
-		config:    config,
-		frameChan: make(chan []byte, 30), // Buffer up to 1 second of frames at 30fps
+// NewMJPEGStreamerWithSource creates a new MJPEG streamer that captures
+// frames from an arbitrary Source, such as RTSPSource
+func NewMJPEGStreamerWithSource(source Source) *MJPEGStreamer {
+	s := &MJPEGStreamer{
+		source:     source,
+		frameChan:  make(chan []byte, 30), // Buffer up to 1 second of frames at 30fps
+		clients:    make(map[*mjpegClient]struct{}),
+		register:   make(chan *mjpegClient),
+		unregister: make(chan *mjpegClient),
+	}
+	go s.dispatch()
+	return s
+}
+
+// dispatch is the single goroutine that owns the clients map. It
+// registers/unregisters viewers and fans out every captured frame to
+// each viewer's bounded channel, dropping the oldest buffered frame for
+// any viewer that isn't keeping up.
+func (s *MJPEGStreamer) dispatch() {
+	for {
+		select {
+		case c := <-s.register:
+			s.clientMu.Lock()
+			s.clients[c] = struct{}{}
+			frame := s.lastFrame
+			s.clientMu.Unlock()
+			if frame != nil {
+				select {
+				case c.frames <- frame:
+				default:
+				}
+			}
+
+		case c := <-s.unregister:
+			s.clientMu.Lock()
+			if _, ok := s.clients[c]; ok {
+				delete(s.clients, c)
+				close(c.frames)
+			}
+			s.clientMu.Unlock()
+
+		case frame := <-s.frameChan:
+			s.clientMu.Lock()
+			s.lastFrame = frame
+			for c := range s.clients {
+				select {
+				case c.frames <- frame:
+				default:
+					// Viewer is behind; drop the oldest buffered frame
+					// to make room rather than stalling the dispatcher.
+					select {
+					case <-c.frames:
+					default:
+					}
+					select {
+					case c.frames <- frame:
+					default:
+					}
+				}
+			}
+			s.clientMu.Unlock()
+		}
+	}
+}
+
+// Subscribe registers a new frame subscriber with the fan-out hub,
+// returning a channel of frames and an unsubscribe function to call once
+// the consumer is done. Unlike ServeHTTP, a subscription is not torn
+// down by Stop(); it ends when the caller unsubscribes.
+func (s *MJPEGStreamer) Subscribe() (frames <-chan []byte, unsubscribe func()) {
+	client := &mjpegClient{
+		frames: make(chan []byte, 2),
+		done:   make(chan struct{}),
 	}
+	s.register <- client
+	return client.frames, func() { s.unregister <- client }
 }
 
 // Start begins capturing and streaming video
@@ -66,12 +136,12 @@ func (s *MJPEGStreamer) Start(ctx context.Context) error {
 	s.running = true
 	s.runningMu.Unlock()
 
-	// Start camera capture
-	if err := s.startCamera(ctx); err != nil {
+	// Start the underlying capture source
+	if err := s.source.Start(ctx, s.frameChan); err != nil {
 		s.runningMu.Lock()
 		s.running = false
 		s.runningMu.Unlock()
-		return fmt.Errorf("failed to start camera: %w", err)
+		return fmt.Errorf("failed to start source: %w", err)
 	}
 
 	log.Println("MJPEG streamer started")
@@ -87,16 +157,12 @@ func (s *MJPEGStreamer) Stop() {
 		return
 	}
 
-	// Stop camera
-	if s.cmdCancel != nil {
-		s.cmdCancel()
-	}
+	s.source.Stop()
 
-	// Disconnect client
+	// Disconnect all viewers
 	s.clientMu.Lock()
-	if s.client != nil {
-		close(s.client.done)
-		s.client = nil
+	for c := range s.clients {
+		close(c.done)
 	}
 	s.clientMu.Unlock()
 
@@ -111,160 +177,18 @@ func (s *MJPEGStreamer) IsRunning() bool {
 	return s.running
 }
 
-// startCamera starts the rpicam-vid subprocess
-func (s *MJPEGStreamer) startCamera(ctx context.Context) error {
-	cmdCtx, cancel := context.WithCancel(ctx)
-	s.cmdCancel = cancel
-
-	// Build rpicam-vid command
-	args := []string{
-		"--timeout", "0", // Run indefinitely
-		"--nopreview", // No preview
-		"--width", fmt.Sprintf("%d", s.config.Width),
-		"--height", fmt.Sprintf("%d", s.config.Height),
-		"--framerate", fmt.Sprintf("%d", s.config.Framerate),
-		"--codec", "mjpeg",
-		"--quality", fmt.Sprintf("%d", s.config.Quality),
-		"--output", "-", // Output to stdout
-	}
-
-	s.cmd = exec.CommandContext(cmdCtx, "rpicam-vid", args...)
-
-	stdout, err := s.cmd.StdoutPipe()
-	if err != nil {
-		cancel()
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	stderr, err := s.cmd.StderrPipe()
-	if err != nil {
-		cancel()
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
-	// Start the process
-	if err := s.cmd.Start(); err != nil {
-		cancel()
-		return fmt.Errorf("failed to start rpicam-vid: %w", err)
-	}
-
-	// Log stderr in background
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			log.Printf("[rpicam-vid] %s", scanner.Text())
-		}
-	}()
-
-	// Read frames from stdout
-	go s.readFrames(stdout)
-
-	// Monitor process
-	go func() {
-		err := s.cmd.Wait()
-		if err != nil && cmdCtx.Err() == nil {
-			log.Printf("rpicam-vid exited with error: %v", err)
-		}
-		cancel()
-	}()
-
-	return nil
-}
-
-// readFrames reads MJPEG frames from the camera output
-func (s *MJPEGStreamer) readFrames(r io.Reader) {
-	frameStart := []byte{0xFF, 0xD8} // JPEG start marker (SOI)
-	frameEnd := []byte{0xFF, 0xD9}   // JPEG end marker (EOI)
-
-	buf := make([]byte, 4096)
-	currentFrame := make([]byte, 0, 64*1024) // Pre-allocate 64KB to avoid reallocations
-	maxFrameSize := 1024 * 1024              // 1MB max per frame
-
-	for {
-		n, err := r.Read(buf)
-		if err != nil {
-			if err != io.EOF {
-				log.Printf("Error reading frames: %v", err)
-			}
-			return
-		}
-
-		if n == 0 {
-			continue
-		}
-
-		data := buf[:n]
-
-		for len(data) > 0 {
-			if len(currentFrame) == 0 {
-				// Looking for frame start
-				startIdx := bytes.Index(data, frameStart)
-				if startIdx == -1 {
-					// No start marker found, skip this data
-					break
-				}
-				// Found start marker
-				currentFrame = append(currentFrame, data[startIdx:]...)
-				data = data[startIdx+len(frameStart):]
-			} else {
-				// Looking for frame end
-				endIdx := bytes.Index(data, frameEnd)
-				if endIdx == -1 {
-					// No end marker yet, append all data
-					currentFrame = append(currentFrame, data...)
-					if len(currentFrame) > maxFrameSize {
-						// Frame too large, discard and start over
-						log.Printf("Frame too large (%d bytes), discarding", len(currentFrame))
-						currentFrame = currentFrame[:0]
-					}
-					break
-				}
-
-				// Found end marker, complete the frame
-				currentFrame = append(currentFrame, data[:endIdx+len(frameEnd)]...)
-
-				// Send frame to channel
-				frame := make([]byte, len(currentFrame))
-				copy(frame, currentFrame)
-				select {
-				case s.frameChan <- frame:
-				default:
-					// Drop frame if channel is full (backpressure)
-				}
-
-				// Reset for next frame
-				currentFrame = currentFrame[:0]
-				data = data[endIdx+len(frameEnd):]
-			}
-		}
-	}
-}
-
-// ServeHTTP handles HTTP requests for MJPEG stream
+// ServeHTTP handles HTTP requests for MJPEG stream, registering the
+// requester as a new viewer in the fan-out hub
 func (s *MJPEGStreamer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Check if already streaming to a client
-	s.clientMu.Lock()
-	if s.client != nil {
-		s.clientMu.Unlock()
-		http.Error(w, "Stream already in use", http.StatusConflict)
-		return
-	}
-
-	// Create new client
 	client := &mjpegClient{
-		writer: w,
+		frames: make(chan []byte, 2),
 		done:   make(chan struct{}),
 	}
-	s.client = client
-	s.clientMu.Unlock()
+	s.register <- client
 
 	// Cleanup on disconnect
 	defer func() {
-		s.clientMu.Lock()
-		if s.client == client {
-			s.client = nil
-		}
-		s.clientMu.Unlock()
+		s.unregister <- client
 		log.Printf("Client disconnected: %s", r.RemoteAddr)
 	}()
 
@@ -295,7 +219,10 @@ func (s *MJPEGStreamer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		case <-r.Context().Done():
 			return
-		case frame := <-s.frameChan:
+		case frame, ok := <-client.frames:
+			if !ok {
+				return
+			}
 			// Create part headers
 			partHeader := textproto.MIMEHeader{}
 			partHeader.Set("Content-Type", "image/jpeg")
@@ -0,0 +1,122 @@
+package video
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeSource is a Source whose Start just hands the test direct access to
+// the channel dispatch reads from, instead of spawning any real capture
+// process.
+type fakeSource struct {
+	frames chan<- []byte
+}
+
+func (f *fakeSource) Start(ctx context.Context, frames chan<- []byte) error {
+	f.frames = frames
+	return nil
+}
+
+func (f *fakeSource) Stop() {}
+
+func newRunningTestStreamer(t *testing.T) (*MJPEGStreamer, *fakeSource) {
+	t.Helper()
+	src := &fakeSource{}
+	s := NewMJPEGStreamerWithSource(src)
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(s.Stop)
+	return s, src
+}
+
+// TestMJPEGStreamerSubscribeReceivesFrames verifies that a subscriber
+// registered via Subscribe receives frames the source pushes afterwards.
+func TestMJPEGStreamerSubscribeReceivesFrames(t *testing.T) {
+	s, src := newRunningTestStreamer(t)
+
+	frames, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	want := []byte("frame-1")
+	src.frames <- want
+
+	select {
+	case got := <-frames:
+		if string(got) != string(want) {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for frame")
+	}
+}
+
+// TestMJPEGStreamerSubscribeReplaysLastFrame verifies that a subscriber
+// joining after frames have already started flowing is immediately
+// handed the most recent one, rather than waiting for the next dispatch,
+// matching the register case in dispatch that seeds a new client's
+// channel from lastFrame.
+func TestMJPEGStreamerSubscribeReplaysLastFrame(t *testing.T) {
+	s, src := newRunningTestStreamer(t)
+
+	first, unsubscribeFirst := s.Subscribe()
+	src.frames <- []byte("frame-1")
+	select {
+	case <-first:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for first subscriber's frame")
+	}
+	unsubscribeFirst()
+
+	late, unsubscribeLate := s.Subscribe()
+	defer unsubscribeLate()
+
+	select {
+	case got := <-late:
+		if string(got) != "frame-1" {
+			t.Fatalf("got %q, want replayed last frame %q", got, "frame-1")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for replayed last frame")
+	}
+}
+
+// TestMJPEGStreamerDropsOldestFrameWhenClientFallsBehind verifies
+// dispatch's backpressure handling: a subscriber that isn't reading fast
+// enough to keep its small buffered channel from filling up gets the
+// oldest buffered frame dropped to make room for the newest, rather than
+// stalling every other subscriber or the dispatcher itself.
+func TestMJPEGStreamerDropsOldestFrameWhenClientFallsBehind(t *testing.T) {
+	s, src := newRunningTestStreamer(t)
+
+	frames, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	// mjpegClient's channel buffers 2 frames; push 3 without reading any
+	// of them so the third push must drop frame-1 to make room.
+	for _, f := range [][]byte{[]byte("frame-1"), []byte("frame-2"), []byte("frame-3")} {
+		src.frames <- f
+		time.Sleep(10 * time.Millisecond) // let dispatch process this push before the next
+	}
+
+	var got [][]byte
+	for i := 0; i < 2; i++ {
+		select {
+		case f := <-frames:
+			got = append(got, f)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for buffered frame %d", i+1)
+		}
+	}
+
+	if string(got[0]) != "frame-2" || string(got[1]) != "frame-3" {
+		t.Fatalf("got %q, %q; want %q, %q after oldest frame was dropped", got[0], got[1], "frame-2", "frame-3")
+	}
+
+	select {
+	case extra := <-frames:
+		t.Fatalf("expected no further buffered frames, got %q", extra)
+	default:
+	}
+}
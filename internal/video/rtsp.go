@@ -0,0 +1,180 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph265"
+	"github.com/pion/rtp"
+)
+
+// RTSPConfig holds connection parameters for an RTSPSource.
+type RTSPConfig struct {
+	URL string // rtsp://[user:pass@]host:port/path
+}
+
+// RTSPSource connects to an RTSP stream - an IP camera or an
+// HDMI-to-RTSP encoder, for example - instead of a local V4L2 device.
+// It depayloads the stream's H.264 or H.265 video with gortsplib/pion
+// and pipes the resulting Annex-B byte stream through ffmpeg to
+// transcode it to MJPEG, since reimplementing a video decoder and JPEG
+// encoder here isn't worth it. ffmpeg's stdout is scanned for frames the
+// same way V4L2Source scans rpicam-vid's output, so both sources feed
+// MJPEGStreamer identically.
+type RTSPSource struct {
+	config RTSPConfig
+
+	client    *gortsplib.Client
+	cmd       *exec.Cmd
+	cmdCancel context.CancelFunc
+}
+
+// NewRTSPSource creates a new RTSPSource with the given configuration.
+func NewRTSPSource(config RTSPConfig) *RTSPSource {
+	return &RTSPSource{config: config}
+}
+
+// Start connects to the RTSP URL and streams MJPEG frames transcoded
+// from its H.264 video - or its H.265 video when the stream has no H.264
+// track - into frames until ctx is canceled or Stop is called.
+func (r *RTSPSource) Start(ctx context.Context, frames chan<- []byte) error {
+	u, err := base.ParseURL(r.config.URL)
+	if err != nil {
+		return fmt.Errorf("invalid RTSP URL: %w", err)
+	}
+
+	cmdCtx, cancel := context.WithCancel(ctx)
+	r.cmdCancel = cancel
+
+	r.client = &gortsplib.Client{}
+	if err := r.client.Start(u.Scheme, u.Host); err != nil {
+		cancel()
+		return fmt.Errorf("failed to connect to RTSP server: %w", err)
+	}
+
+	desc, _, err := r.client.Describe(u)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to describe RTSP stream: %w", err)
+	}
+
+	var h264Format *format.H264
+	var h265Format *format.H265
+	var codecFormat format.Format
+	var ffmpegInputFormat string
+	var decode func(*rtp.Packet) ([][]byte, error)
+
+	media := desc.FindFormat(&h264Format)
+	switch {
+	case media != nil:
+		codecFormat = h264Format
+		ffmpegInputFormat = "h264"
+
+		decoder := &rtph264.Decoder{}
+		if err := decoder.Init(); err != nil {
+			cancel()
+			return fmt.Errorf("failed to init H.264 depacketizer: %w", err)
+		}
+		decode = decoder.Decode
+
+	default:
+		media = desc.FindFormat(&h265Format)
+		if media == nil {
+			cancel()
+			return fmt.Errorf("RTSP stream has no H.264 or H.265 video track")
+		}
+		codecFormat = h265Format
+		ffmpegInputFormat = "hevc"
+
+		decoder := &rtph265.Decoder{}
+		if err := decoder.Init(); err != nil {
+			cancel()
+			return fmt.Errorf("failed to init H.265 depacketizer: %w", err)
+		}
+		decode = decoder.Decode
+	}
+
+	if _, err := r.client.Setup(desc.BaseURL, media, 0, 0); err != nil {
+		cancel()
+		return fmt.Errorf("failed to set up RTSP media: %w", err)
+	}
+
+	// ffmpeg reads raw Annex-B H.264/H.265 from stdin and writes MJPEG to
+	// stdout, mirroring the rpicam-vid pipeline used by V4L2Source.
+	ffmpeg := exec.CommandContext(cmdCtx, "ffmpeg",
+		"-f", ffmpegInputFormat, "-i", "-",
+		"-f", "mjpeg", "-q:v", "5", "-",
+	)
+
+	stdin, err := ffmpeg.StdinPipe()
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to create ffmpeg stdin pipe: %w", err)
+	}
+
+	stdout, err := ffmpeg.StdoutPipe()
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to create ffmpeg stdout pipe: %w", err)
+	}
+
+	if err := ffmpeg.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	r.cmd = ffmpeg
+
+	startCode := []byte{0x00, 0x00, 0x00, 0x01}
+	r.client.OnPacketRTP(media, codecFormat, func(pkt *rtp.Packet) {
+		nalus, err := decode(pkt)
+		if err != nil {
+			return
+		}
+		for _, nalu := range nalus {
+			if _, err := stdin.Write(startCode); err != nil {
+				return
+			}
+			if _, err := stdin.Write(nalu); err != nil {
+				return
+			}
+		}
+	})
+
+	if _, err := r.client.Play(nil); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start RTSP playback: %w", err)
+	}
+
+	go scanMJPEGFrames(stdout, frames)
+
+	go func() {
+		<-cmdCtx.Done()
+		_ = stdin.Close()
+	}()
+
+	go func() {
+		err := ffmpeg.Wait()
+		if err != nil && cmdCtx.Err() == nil {
+			log.Printf("ffmpeg (RTSP transcode) exited with error: %v", err)
+		}
+		cancel()
+	}()
+
+	return nil
+}
+
+// Stop tears down the RTSP session and the ffmpeg transcode process.
+func (r *RTSPSource) Stop() {
+	if r.client != nil {
+		r.client.Close()
+	}
+	if r.cmdCancel != nil {
+		r.cmdCancel()
+	}
+}
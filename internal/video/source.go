@@ -0,0 +1,12 @@
+package video
+
+import "context"
+
+// Source captures MJPEG frames from some underlying device or stream
+// and writes them to frames until ctx is canceled or Stop is called.
+// V4L2Source and RTSPSource are the two implementations; MJPEGStreamer
+// is source-agnostic and works with either.
+type Source interface {
+	Start(ctx context.Context, frames chan<- []byte) error
+	Stop()
+}
@@ -0,0 +1,99 @@
+package video
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+)
+
+// Config holds configuration for a V4L2Source
+type Config struct {
+	Width     int
+	Height    int
+	Framerate int
+	Quality   int
+}
+
+// V4L2Source captures MJPEG frames from a local V4L2 device by running
+// rpicam-vid and scanning its stdout for JPEG frames.
+type V4L2Source struct {
+	config Config
+
+	cmd       *exec.Cmd
+	cmdCancel context.CancelFunc
+}
+
+// NewV4L2Source creates a new V4L2Source with the given configuration.
+func NewV4L2Source(config Config) *V4L2Source {
+	return &V4L2Source{config: config}
+}
+
+// Start launches the rpicam-vid subprocess and streams the MJPEG frames
+// it produces into frames until ctx is canceled or Stop is called.
+func (v *V4L2Source) Start(ctx context.Context, frames chan<- []byte) error {
+	cmdCtx, cancel := context.WithCancel(ctx)
+	v.cmdCancel = cancel
+
+	// Build rpicam-vid command
+	args := []string{
+		"--timeout", "0", // Run indefinitely
+		"--nopreview", // No preview
+		"--width", fmt.Sprintf("%d", v.config.Width),
+		"--height", fmt.Sprintf("%d", v.config.Height),
+		"--framerate", fmt.Sprintf("%d", v.config.Framerate),
+		"--codec", "mjpeg",
+		"--quality", fmt.Sprintf("%d", v.config.Quality),
+		"--output", "-", // Output to stdout
+	}
+
+	v.cmd = exec.CommandContext(cmdCtx, "rpicam-vid", args...)
+
+	stdout, err := v.cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	stderr, err := v.cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	// Start the process
+	if err := v.cmd.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start rpicam-vid: %w", err)
+	}
+
+	// Log stderr in background
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("[rpicam-vid] %s", scanner.Text())
+		}
+	}()
+
+	// Read frames from stdout
+	go scanMJPEGFrames(stdout, frames)
+
+	// Monitor process
+	go func() {
+		err := v.cmd.Wait()
+		if err != nil && cmdCtx.Err() == nil {
+			log.Printf("rpicam-vid exited with error: %v", err)
+		}
+		cancel()
+	}()
+
+	return nil
+}
+
+// Stop terminates the rpicam-vid process, if running.
+func (v *V4L2Source) Stop() {
+	if v.cmdCancel != nil {
+		v.cmdCancel()
+	}
+}
@@ -0,0 +1,312 @@
+// Package webrtc provides WHEP-based low-latency video streaming as an
+// alternative to the MJPEG/ustreamer pipeline.
+package webrtc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os/exec"
+	"sync"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+)
+
+// Config holds configuration for the WHEP/WebRTC video pipeline.
+type Config struct {
+	Device     string
+	Width      int
+	Height     int
+	Framerate  int
+	Bitrate    int // in kbps
+	ICEServers []webrtc.ICEServer
+	PublicIP   string
+}
+
+// DefaultConfig returns sensible defaults for the ffmpeg encoding pipeline.
+func DefaultConfig(device string) Config {
+	return Config{
+		Device:    device,
+		Width:     1280,
+		Height:    720,
+		Framerate: 30,
+		Bitrate:   2000,
+	}
+}
+
+// Streamer negotiates WHEP sessions and feeds an ffmpeg-encoded H.264
+// pipeline into each resulting peer connection over RTP.
+type Streamer struct {
+	config Config
+
+	// ctx outlives any single WHEP negotiation's request context, so the
+	// ffmpeg pipeline started in startPipeline keeps running after
+	// HandleWHEP returns instead of being killed the instant net/http
+	// cancels r.Context().
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Pipeline
+	cmd       *exec.Cmd
+	cmdCancel context.CancelFunc
+	rtpConn   *net.UDPConn
+
+	// Negotiated session
+	mu    sync.Mutex
+	pc    *webrtc.PeerConnection
+	track *webrtc.TrackLocalStaticRTP
+
+	running   bool
+	runningMu sync.Mutex
+}
+
+// NewStreamer creates a new WHEP streamer
+func NewStreamer(config Config) *Streamer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Streamer{config: config, ctx: ctx, cancel: cancel}
+}
+
+// IsRunning returns whether the encoding pipeline is currently running
+func (s *Streamer) IsRunning() bool {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+	return s.running
+}
+
+// Stop tears down the encoding pipeline and closes the active peer
+// connection, if any.
+func (s *Streamer) Stop() {
+	s.runningMu.Lock()
+	if s.running {
+		if s.cmdCancel != nil {
+			s.cmdCancel()
+		}
+		if s.rtpConn != nil {
+			_ = s.rtpConn.Close()
+		}
+		s.running = false
+	}
+	s.runningMu.Unlock()
+
+	s.mu.Lock()
+	pc := s.pc
+	s.pc = nil
+	s.track = nil
+	s.mu.Unlock()
+
+	if pc != nil {
+		if err := pc.Close(); err != nil {
+			log.Printf("Error closing WebRTC peer connection: %v", err)
+		}
+	}
+
+	log.Println("WebRTC streamer stopped")
+}
+
+// startPipeline starts the ffmpeg encoding pipeline, which writes RTP
+// packets for the running session to a loopback UDP port that readRTP
+// forwards onto the negotiated track. It runs off s.ctx rather than the
+// context of the WHEP request that triggered it, since that request's
+// context is canceled by net/http the moment HandleWHEP returns - long
+// before the viewing session is over.
+func (s *Streamer) startPipeline() error {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return fmt.Errorf("failed to bind RTP listen socket: %w", err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	pipeCtx, cancel := context.WithCancel(s.ctx)
+
+	args := []string{
+		"-f", "v4l2",
+		"-framerate", fmt.Sprintf("%d", s.config.Framerate),
+		"-video_size", fmt.Sprintf("%dx%d", s.config.Width, s.config.Height),
+		"-i", s.config.Device,
+		"-c:v", "h264_v4l2m2m", // hardware encode where the SoC supports it
+		"-b:v", fmt.Sprintf("%dk", s.config.Bitrate),
+		"-g", fmt.Sprintf("%d", s.config.Framerate*2),
+		"-pix_fmt", "yuv420p",
+		"-f", "rtp",
+		"-payload_type", "96",
+		fmt.Sprintf("rtp://127.0.0.1:%d", port),
+	}
+
+	log.Printf("Starting ffmpeg WebRTC pipeline: %dx%d @ %dfps, %dkbps", s.config.Width, s.config.Height, s.config.Framerate, s.config.Bitrate)
+	cmd := exec.CommandContext(pipeCtx, "ffmpeg", args...)
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		_ = conn.Close()
+		return fmt.Errorf("failed to create ffmpeg stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		_ = conn.Close()
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	s.cmd = cmd
+	s.cmdCancel = cancel
+	s.rtpConn = conn
+
+	go logPipeOutput(stderr)
+	go s.readRTP(conn)
+	go func() {
+		if err := cmd.Wait(); err != nil && pipeCtx.Err() == nil {
+			log.Printf("ffmpeg exited with error: %v", err)
+		}
+		cancel()
+	}()
+
+	return nil
+}
+
+func logPipeOutput(r io.Reader) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			log.Printf("[ffmpeg] %s", buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readRTP reads RTP packets from the ffmpeg pipeline and forwards them
+// to the currently negotiated track, if any.
+func (s *Streamer) readRTP(conn *net.UDPConn) {
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		pkt := &rtp.Packet{}
+		if err := pkt.Unmarshal(buf[:n]); err != nil {
+			log.Printf("Failed to unmarshal RTP packet: %v", err)
+			continue
+		}
+
+		s.mu.Lock()
+		track := s.track
+		s.mu.Unlock()
+
+		if track == nil {
+			continue
+		}
+		if err := track.WriteRTP(pkt); err != nil {
+			log.Printf("Failed to write RTP packet to track: %v", err)
+		}
+	}
+}
+
+// HandleWHEP negotiates a new WHEP session: it accepts an SDP offer in
+// the request body, sets up a peer connection carrying the H.264 track,
+// and responds with the SDP answer. Only one session is supported at a
+// time; a new offer replaces any existing session.
+func (s *Streamer) HandleWHEP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	offer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	s.Stop()
+
+	track, err := webrtc.NewTrackLocalStaticRTP(
+		webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264},
+		"video", "kindavm",
+	)
+	if err != nil {
+		log.Printf("Failed to create WebRTC track: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{ICEServers: s.config.ICEServers})
+	if err != nil {
+		log.Printf("Failed to create peer connection: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := pc.AddTrack(track); err != nil {
+		log.Printf("Failed to add track to peer connection: %v", err)
+		_ = pc.Close()
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeOffer,
+		SDP:  string(offer),
+	}); err != nil {
+		log.Printf("Failed to set remote description: %v", err)
+		_ = pc.Close()
+		http.Error(w, "Invalid SDP offer", http.StatusBadRequest)
+		return
+	}
+
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		log.Printf("Failed to create answer: %v", err)
+		_ = pc.Close()
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	gatherComplete := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(answer); err != nil {
+		log.Printf("Failed to set local description: %v", err)
+		_ = pc.Close()
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	<-gatherComplete
+
+	s.mu.Lock()
+	s.pc = pc
+	s.track = track
+	s.mu.Unlock()
+
+	s.runningMu.Lock()
+	needsStart := !s.running
+	if needsStart {
+		s.running = true
+	}
+	s.runningMu.Unlock()
+
+	if needsStart {
+		if err := s.startPipeline(); err != nil {
+			log.Printf("Failed to start WebRTC pipeline: %v", err)
+			s.runningMu.Lock()
+			s.running = false
+			s.runningMu.Unlock()
+			_ = pc.Close()
+			http.Error(w, "Failed to start video pipeline", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/sdp")
+	w.Header().Set("Location", r.URL.Path)
+	w.WriteHeader(http.StatusCreated)
+	if _, err := w.Write([]byte(pc.LocalDescription().SDP)); err != nil {
+		log.Printf("Failed to write SDP answer: %v", err)
+	}
+}
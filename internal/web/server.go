@@ -4,6 +4,7 @@ package web
 import (
 	"context"
 	"embed"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io/fs"
@@ -12,11 +13,19 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/Ch00k/kindavm/internal/discovery"
 	"github.com/Ch00k/kindavm/internal/events"
+	"github.com/Ch00k/kindavm/internal/video"
+	"github.com/Ch00k/kindavm/internal/video/broadcast"
+	"github.com/Ch00k/kindavm/internal/video/hls"
+	"github.com/Ch00k/kindavm/internal/video/webrtc"
 	"github.com/coder/websocket"
+	pionwebrtc "github.com/pion/webrtc/v4"
 )
 
 //go:embed static/*
@@ -24,10 +33,11 @@ var staticFiles embed.FS
 
 // VideoSettings holds configurable ustreamer parameters
 type VideoSettings struct {
-	Quality    int  `json:"quality"`
-	DesiredFPS int  `json:"desiredFps"`
-	Buffers    int  `json:"buffers"`
-	TCPNodelay bool `json:"tcpNodelay"`
+	Quality    int    `json:"quality"`
+	DesiredFPS int    `json:"desiredFps"`
+	Buffers    int    `json:"buffers"`
+	TCPNodelay bool   `json:"tcpNodelay"`
+	Backend    string `json:"backend"` // "mjpeg" (default) or "webrtc"
 }
 
 // DefaultVideoSettings returns the default video settings
@@ -37,38 +47,108 @@ func DefaultVideoSettings() VideoSettings {
 		DesiredFPS: 30,
 		Buffers:    5,
 		TCPNodelay: false,
+		Backend:    VideoBackendMJPEG,
 	}
 }
 
+// Supported video backend identifiers for VideoSettings.Backend.
+const (
+	VideoBackendMJPEG  = "mjpeg"
+	VideoBackendWebRTC = "webrtc"
+)
+
+// Supported video source kinds for SourceConfig.Kind.
+const (
+	SourceKindV4L2 = "v4l2"
+	SourceKindRTSP = "rtsp"
+)
+
+// SourceConfig describes where to capture the MJPEG video feed from:
+// either a local V4L2 device (the default) or a remote RTSP stream, such
+// as an IP camera or an HDMI-to-RTSP encoder. WebRTC/WHEP and
+// broadcast/recording still assume a local V4L2 device regardless of
+// Kind; only the MJPEG pipeline supports RTSP so far.
+type SourceConfig struct {
+	Kind    string // SourceKindV4L2 (default) or SourceKindRTSP
+	Device  string // V4L2 device path, used when Kind == SourceKindV4L2
+	RTSPURL string // rtsp://[user:pass@]host:port/path, used when Kind == SourceKindRTSP
+}
+
 // Server represents the HTTP server with WebSocket support
 type Server struct {
 	handler       *events.Handler
 	addr          string
 	ustreamerAddr string
 	videoDevice   string
+	sourceConfig  SourceConfig
 
-	// ustreamer process management
+	// ustreamer process management (SourceKindV4L2)
 	ustreamerCmd *exec.Cmd
 	ustreamerMu  sync.Mutex
 
+	// Internal MJPEG pipeline (SourceKindRTSP)
+	mjpegStreamer *video.MJPEGStreamer
+	mjpegHTTPSrv  *http.Server
+
+	// WebRTC/WHEP streaming
+	webrtcStreamer *webrtc.Streamer
+
+	// Recording/broadcasting
+	broadcastMgr *broadcast.Manager
+
+	// HLS output
+	hlsMgr *hls.Manager
+
+	// Binary frame push over /ws, lazily started by the "mjpeg-ts"
+	// subscribe negotiation. It rides whichever capture pipeline
+	// startUstreamer already has running rather than opening a second
+	// one: for SourceKindRTSP the push uses s.mjpegStreamer itself and
+	// frameStreamer stays nil; for SourceKindV4L2 frameStreamer is backed
+	// by an HTTPMJPEGSource scraping the running ustreamer process's own
+	// MJPEG endpoint (see ensureFrameStreamerRunning).
+	frameStreamer          *video.MJPEGStreamer
+	frameStreamerMu        sync.Mutex
+	frameStreamerStartedAt time.Time
+
 	// Video settings
 	videoSettings VideoSettings
 	settingsMu    sync.RWMutex
+
+	// Info served at GET /info for LAN discovery front-ends
+	discoveryInfo discovery.Info
 }
 
-// NewServer creates a new web server
+// NewServer creates a new web server. source describes where to capture
+// the MJPEG feed from; iceServers is a list of STUN/TURN URLs (e.g.
+// "stun:stun.l.google.com:19302") advertised to WebRTC/WHEP clients;
+// publicIP, when set, is used by the peer connection as the NAT 1:1
+// mapping for hosts behind a static public address.
 func NewServer(
 	addr string,
 	handler *events.Handler,
 	ustreamerAddr string,
-	videoDevice string,
+	source SourceConfig,
+	iceServers []string,
+	publicIP string,
+	discoveryInfo discovery.Info,
 ) *Server {
+	webrtcConfig := webrtc.DefaultConfig(source.Device)
+	webrtcConfig.PublicIP = publicIP
+	for _, url := range iceServers {
+		webrtcConfig.ICEServers = append(webrtcConfig.ICEServers, pionwebrtc.ICEServer{URLs: []string{url}})
+	}
+
 	return &Server{
-		addr:          addr,
-		handler:       handler,
-		ustreamerAddr: ustreamerAddr,
-		videoDevice:   videoDevice,
-		videoSettings: DefaultVideoSettings(),
+		addr:           addr,
+		handler:        handler,
+		ustreamerAddr:  ustreamerAddr,
+		videoDevice:    source.Device,
+		sourceConfig:   source,
+		webrtcStreamer: webrtc.NewStreamer(webrtcConfig),
+		broadcastMgr:   broadcast.NewManager(),
+		hlsMgr:         hls.NewManager(hls.DefaultConfig(source.Device)),
+		videoSettings:  DefaultVideoSettings(),
+		discoveryInfo:  discoveryInfo,
 	}
 }
 
@@ -130,6 +210,150 @@ func (s *Server) handleHostname(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// handleClipboard returns the clipboard text most recently captured
+// server-side from the target host (e.g. via a serial/UART bridge or
+// drop-box file), since HID has no path to read the guest clipboard
+// directly. Returns an empty string if nothing has been captured.
+func (s *Server) handleClipboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"text": s.handler.ClipboardText()}); err != nil {
+		log.Printf("Error encoding clipboard response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handleMacroRecordStart begins recording every subsequent input event
+// into a new in-progress macro
+func (s *Server) handleMacroRecordStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.handler.StartRecording()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "recording"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleMacroRecordStop ends the in-progress macro recording, saving it
+// under the "name" query parameter
+func (s *Server) handleMacroRecordStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "Missing name query parameter", http.StatusBadRequest)
+		return
+	}
+
+	script, err := s.handler.StopRecording(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(script); err != nil {
+		log.Printf("Error encoding macro script: %v", err)
+	}
+}
+
+// handleMacro dispatches GET/POST /macros/{name} and POST
+// /macros/{name}/play, routing on the path since the repo's mux doesn't
+// support path parameters.
+func (s *Server) handleMacro(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/macros/")
+	if rest == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if name, ok := strings.CutSuffix(rest, "/play"); ok {
+		s.handleMacroPlay(w, r, name)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleMacroGet(w, r, rest)
+	case http.MethodPost:
+		s.handleMacroSave(w, r, rest)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleMacroGet returns the named macro script as JSON
+func (s *Server) handleMacroGet(w http.ResponseWriter, r *http.Request, name string) {
+	script, ok := s.handler.Macro(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(script); err != nil {
+		log.Printf("Error encoding macro script: %v", err)
+	}
+}
+
+// handleMacroSave stores the macro script in the request body under name,
+// e.g. to restore a previously exported macro
+func (s *Server) handleMacroSave(w http.ResponseWriter, r *http.Request, name string) {
+	var script events.MacroScript
+	if err := json.NewDecoder(r.Body).Decode(&script); err != nil {
+		log.Printf("Error decoding macro script: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	s.handler.SaveMacro(name, script)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "saved"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleMacroPlay replays the named macro, blocking until playback
+// finishes or the client disconnects, at which point played-back input
+// is released as if every key and mouse button had been lifted.
+func (s *Server) handleMacroPlay(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	speed := 1.0
+	if sp := r.URL.Query().Get("speed"); sp != "" {
+		if parsed, err := strconv.ParseFloat(sp, 64); err == nil && parsed > 0 {
+			speed = parsed
+		}
+	}
+
+	if err := s.handler.PlayMacro(r.Context(), name, speed); err != nil {
+		log.Printf("Macro playback error: %v", err)
+		http.Error(w, fmt.Sprintf("Macro playback failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "played"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
 // handleConfig returns the client configuration as JSON
 func (s *Server) handleConfig(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -147,8 +371,31 @@ func (s *Server) handleConfig(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
-// startUstreamer starts the ustreamer process
+// handleInfo returns this daemon's discovery.Info so a browser
+// front-end can list peers without relying solely on LAN broadcasts
+// (e.g. when querying a peer learned about from another one).
+func (s *Server) handleInfo(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.discoveryInfo); err != nil {
+		log.Printf("Error encoding info: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// startUstreamer starts serving the MJPEG stream on s.ustreamerAddr,
+// dispatching on the configured source kind: the external ustreamer
+// binary for a local V4L2 device, or kindavm's own MJPEG pipeline for a
+// remote RTSP source.
 func (s *Server) startUstreamer() error {
+	if s.sourceConfig.Kind == SourceKindRTSP {
+		return s.startRTSPStreamer()
+	}
+	return s.startV4L2Ustreamer()
+}
+
+// startV4L2Ustreamer starts the ustreamer process against the configured
+// local V4L2 device
+func (s *Server) startV4L2Ustreamer() error {
 	s.ustreamerMu.Lock()
 	defer s.ustreamerMu.Unlock()
 
@@ -203,8 +450,60 @@ func (s *Server) startUstreamer() error {
 	return nil
 }
 
-// stopUstreamer stops the ustreamer process
+// startRTSPStreamer starts kindavm's own MJPEG pipeline against the
+// configured RTSP source and serves it on s.ustreamerAddr, in place of
+// the external ustreamer binary used for a local V4L2 device.
+func (s *Server) startRTSPStreamer() error {
+	s.ustreamerMu.Lock()
+	defer s.ustreamerMu.Unlock()
+
+	if s.mjpegStreamer != nil && s.mjpegStreamer.IsRunning() {
+		return nil // Already running
+	}
+
+	// The streamer itself - and the dispatch goroutine it owns - is
+	// created once and reused across start/stop cycles, the same way
+	// H264Streamer and the other long-lived pipelines work; only the
+	// HTTP server wrapping it is recreated, since Close()ing one doesn't
+	// leave it restartable.
+	if s.mjpegStreamer == nil {
+		s.mjpegStreamer = video.NewMJPEGStreamerWithSource(video.NewRTSPSource(video.RTSPConfig{URL: s.sourceConfig.RTSPURL}))
+	}
+	if err := s.mjpegStreamer.Start(context.Background()); err != nil {
+		return fmt.Errorf("failed to start RTSP source: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", s.mjpegStreamer)
+	httpSrv := &http.Server{Addr: s.ustreamerAddr, Handler: mux}
+
+	go func() {
+		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("RTSP MJPEG server error: %v", err)
+		}
+	}()
+
+	s.mjpegHTTPSrv = httpSrv
+	log.Printf("Streaming RTSP source as MJPEG on %s", s.ustreamerAddr)
+	return nil
+}
+
+// stopUstreamer stops whichever MJPEG pipeline is currently running,
+// along with the /ws frame-push streamer riding on top of it (see
+// ensureFrameStreamerRunning), so a later /video/start doesn't hand a WS
+// subscriber a frameStreamer left pointed at a now-dead connection.
 func (s *Server) stopUstreamer() {
+	defer s.stopFrameStreamer()
+
+	if s.sourceConfig.Kind == SourceKindRTSP {
+		s.stopRTSPStreamer()
+		return
+	}
+	s.stopV4L2Ustreamer()
+}
+
+// stopV4L2Ustreamer stops the ustreamer process
+func (s *Server) stopV4L2Ustreamer() {
 	s.ustreamerMu.Lock()
 	defer s.ustreamerMu.Unlock()
 
@@ -227,6 +526,26 @@ func (s *Server) stopUstreamer() {
 	log.Println("ustreamer stopped")
 }
 
+// stopRTSPStreamer stops the RTSP-backed MJPEG pipeline. The streamer
+// itself is left in place - still holding its one dispatch goroutine -
+// so the next startRTSPStreamer reuses it instead of leaking another.
+func (s *Server) stopRTSPStreamer() {
+	s.ustreamerMu.Lock()
+	defer s.ustreamerMu.Unlock()
+
+	if s.mjpegStreamer == nil || !s.mjpegStreamer.IsRunning() {
+		return
+	}
+
+	s.mjpegStreamer.Stop()
+	if err := s.mjpegHTTPSrv.Close(); err != nil {
+		log.Printf("Error closing RTSP MJPEG server: %v", err)
+	}
+
+	s.mjpegHTTPSrv = nil
+	log.Println("RTSP MJPEG pipeline stopped")
+}
+
 // handleStartVideo starts the ustreamer process
 func (s *Server) handleStartVideo(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -261,6 +580,125 @@ func (s *Server) handleStopVideo(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleStartWebRTC answers a start request for the WebRTC/WHEP pipeline,
+// mirroring /video/start. It does not start the encoding pipeline itself:
+// the pipeline is started by the first WHEP offer.
+func (s *Server) handleStartWebRTC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "started"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleStopWebRTC tears down the active WHEP session and encoding pipeline
+func (s *Server) handleStopWebRTC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.webrtcStreamer.Stop()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "stopped"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleBroadcastStart starts recording/re-broadcasting the live feed to
+// the target URL given in the "target" query parameter
+func (s *Server) handleBroadcastStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "Missing target query parameter", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.broadcastMgr.Start(context.Background(), s.videoDevice, target); err != nil {
+		log.Printf("Failed to start broadcast: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to start broadcast: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "started"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleBroadcastStop stops the active broadcast, if any
+func (s *Server) handleBroadcastStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.broadcastMgr.Stop()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "stopped"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleBroadcastStatus returns the current broadcast status as JSON
+func (s *Server) handleBroadcastStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.broadcastMgr.Status()); err != nil {
+		log.Printf("Error encoding broadcast status: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handleHLSStart starts the HLS segmenter
+func (s *Server) handleHLSStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.hlsMgr.Start(context.Background()); err != nil {
+		log.Printf("Failed to start HLS segmenter: %v", err)
+		http.Error(w, "Failed to start HLS stream", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "started"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleHLSStop stops the HLS segmenter
+func (s *Server) handleHLSStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.hlsMgr.Stop()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "stopped"}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
 // handleGetSettings returns the current video settings
 func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -323,6 +761,14 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 
 // handleConnection handles messages from a WebSocket connection
 func (s *Server) handleConnection(ctx context.Context, conn *websocket.Conn) error {
+	subCtx, cancelSub := context.WithCancel(ctx)
+	defer cancelSub()
+
+	// Release any held keys/modifiers the moment this connection goes
+	// away, however it goes away, so a dropped browser tab never leaves
+	// Ctrl or Shift latched on the guest.
+	s.handler.WatchCancel(subCtx)
+
 	for {
 		msgType, data, err := conn.Read(ctx)
 		if err != nil {
@@ -334,6 +780,10 @@ func (s *Server) handleConnection(ctx context.Context, conn *websocket.Conn) err
 			continue
 		}
 
+		if s.handleNegotiation(subCtx, conn, data) {
+			continue
+		}
+
 		// Process the event
 		if err := s.handler.HandleEvent(data); err != nil {
 			log.Printf("Error handling event: %v", err)
@@ -342,6 +792,140 @@ func (s *Server) handleConnection(ctx context.Context, conn *websocket.Conn) err
 	}
 }
 
+// wsNegotiation is the text message a client sends on connect to opt
+// into a server->client transport, e.g. {"type":"subscribe","format":"mjpeg-ts"}.
+type wsNegotiation struct {
+	Type   string `json:"type"`
+	Format string `json:"format"`
+}
+
+// handleNegotiation checks whether data is a transport negotiation
+// message rather than an input event, acting on it and reporting true
+// if so.
+func (s *Server) handleNegotiation(ctx context.Context, conn *websocket.Conn, data []byte) bool {
+	var n wsNegotiation
+	if err := json.Unmarshal(data, &n); err != nil || n.Type != "subscribe" {
+		return false
+	}
+
+	switch n.Format {
+	case "mjpeg-ts":
+		go s.streamFramesOverWS(ctx, conn)
+	default:
+		log.Printf("Unknown subscribe format: %q", n.Format)
+	}
+	return true
+}
+
+// ensureFrameStreamerRunning lazily wires up the MJPEG fan-out used to
+// push frames over /ws, recording the time it started so pushed
+// timestamps are monotonic micros since stream start. It never opens a
+// second capture of its own: it first makes sure startUstreamer's
+// pipeline is running, then either reuses s.mjpegStreamer directly
+// (SourceKindRTSP) or taps that pipeline's own MJPEG HTTP endpoint via
+// an HTTPMJPEGSource (SourceKindV4L2), so a WS subscriber never causes a
+// second rpicam-vid/RTSP session to contend with the one /video/start
+// already has running.
+func (s *Server) ensureFrameStreamerRunning() (*video.MJPEGStreamer, error) {
+	s.frameStreamerMu.Lock()
+	defer s.frameStreamerMu.Unlock()
+
+	if err := s.startUstreamer(); err != nil {
+		return nil, fmt.Errorf("failed to start video pipeline: %w", err)
+	}
+
+	if s.frameStreamerStartedAt.IsZero() {
+		s.frameStreamerStartedAt = time.Now()
+	}
+
+	if s.sourceConfig.Kind == SourceKindRTSP {
+		return s.mjpegStreamer, nil
+	}
+
+	// The streamer - and its dispatch goroutine - is created once and
+	// reused across start/stop cycles, the same way s.mjpegStreamer is
+	// for SourceKindRTSP, rather than rebuilt (and leaked) on every lazy
+	// subscribe-after-stop.
+	if s.frameStreamer == nil {
+		streamURL := fmt.Sprintf("http://%s/stream", s.ustreamerAddr)
+		s.frameStreamer = video.NewMJPEGStreamerWithSource(video.NewHTTPMJPEGSource(streamURL))
+	}
+	if !s.frameStreamer.IsRunning() {
+		if err := s.frameStreamer.Start(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to start frame streamer: %w", err)
+		}
+	}
+	return s.frameStreamer, nil
+}
+
+// stopFrameStreamer stops the HTTPMJPEGSource-backed fan-out used for
+// SourceKindV4L2, if one was ever started, and clears
+// frameStreamerStartedAt so the next ensureFrameStreamerRunning call
+// re-bases WS timestamps on when the new stream actually starts rather
+// than carrying over the stopped interval. For SourceKindRTSP the push
+// uses s.mjpegStreamer, already stopped by stopUstreamer, and
+// frameStreamer is nil. The streamer itself is left in place - still holding
+// its one dispatch goroutine - so the next ensureFrameStreamerRunning
+// reuses it instead of leaking another.
+func (s *Server) stopFrameStreamer() {
+	s.frameStreamerMu.Lock()
+	defer s.frameStreamerMu.Unlock()
+
+	s.frameStreamerStartedAt = time.Time{}
+
+	if s.sourceConfig.Kind == SourceKindRTSP {
+		return
+	}
+	if s.frameStreamer != nil && s.frameStreamer.IsRunning() {
+		s.frameStreamer.Stop()
+	}
+}
+
+// streamFramesOverWS subscribes to the frame streamer's fan-out and
+// pushes each frame to conn as a binary message prefixed with an 8-byte
+// big-endian timestamp (monotonic micros since stream start) and a
+// 2-byte sequence number, letting the client measure latency and
+// jitter. The timestamp is taken when the frame is received from the
+// subscription, just before it is written; it is not the time the frame
+// was captured. It runs until ctx is canceled (on /ws disconnect)
+// or the write fails.
+func (s *Server) streamFramesOverWS(ctx context.Context, conn *websocket.Conn) {
+	streamer, err := s.ensureFrameStreamerRunning()
+	if err != nil {
+		log.Printf("Failed to start frame streamer for WebSocket push: %v", err)
+		return
+	}
+
+	s.frameStreamerMu.Lock()
+	startedAt := s.frameStreamerStartedAt
+	s.frameStreamerMu.Unlock()
+
+	frames, unsubscribe := streamer.Subscribe()
+	defer unsubscribe()
+
+	var seq uint16
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame, ok := <-frames:
+			if !ok {
+				return
+			}
+
+			msg := make([]byte, 10+len(frame))
+			binary.BigEndian.PutUint64(msg[0:8], uint64(time.Since(startedAt).Microseconds()))
+			binary.BigEndian.PutUint16(msg[8:10], seq)
+			copy(msg[10:], frame)
+			seq++
+
+			if err := conn.Write(ctx, websocket.MessageBinary, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
 // Run starts the server with graceful shutdown support
 func (s *Server) Run(ctx context.Context) error {
 	srv := &http.Server{
@@ -365,8 +949,23 @@ func (s *Server) Run(ctx context.Context) error {
 	// API endpoints
 	mux.HandleFunc("/hostname", s.handleHostname)
 	mux.HandleFunc("/config", s.handleConfig)
+	mux.HandleFunc("/info", s.handleInfo)
+	mux.HandleFunc("/clipboard", s.handleClipboard)
+	mux.HandleFunc("/macros/record/start", s.handleMacroRecordStart)
+	mux.HandleFunc("/macros/record/stop", s.handleMacroRecordStop)
+	mux.HandleFunc("/macros/", s.handleMacro)
 	mux.HandleFunc("/video/start", s.handleStartVideo)
 	mux.HandleFunc("/video/stop", s.handleStopVideo)
+	mux.HandleFunc("/video/webrtc/start", s.handleStartWebRTC)
+	mux.HandleFunc("/video/webrtc/stop", s.handleStopWebRTC)
+	mux.HandleFunc("/video/whep", s.webrtcStreamer.HandleWHEP)
+	mux.HandleFunc("/broadcast/start", s.handleBroadcastStart)
+	mux.HandleFunc("/broadcast/stop", s.handleBroadcastStop)
+	mux.HandleFunc("/broadcast/status", s.handleBroadcastStatus)
+	mux.HandleFunc("/video/hls/start", s.handleHLSStart)
+	mux.HandleFunc("/video/hls/stop", s.handleHLSStop)
+	mux.HandleFunc("/video/hls/stream.m3u8", s.hlsMgr.ServePlaylist)
+	mux.HandleFunc("/video/hls/", s.hlsMgr.ServeSegment)
 	mux.HandleFunc("/settings", s.handleGetSettings)
 	mux.HandleFunc("/settings/update", s.handleUpdateSettings)
 
@@ -386,11 +985,17 @@ func (s *Server) Run(ctx context.Context) error {
 	case <-ctx.Done():
 		log.Println("Shutting down server...")
 		s.stopUstreamer()
+		s.webrtcStreamer.Stop()
+		s.broadcastMgr.Stop()
+		s.hlsMgr.Stop()
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		return srv.Shutdown(shutdownCtx)
 	case err := <-errChan:
 		s.stopUstreamer()
+		s.webrtcStreamer.Stop()
+		s.broadcastMgr.Stop()
+		s.hlsMgr.Stop()
 		return err
 	}
 }